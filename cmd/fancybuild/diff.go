@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/diff"
+)
+
+var diffCommand = &command{
+	Name:        "diff",
+	Usage:       "diff [-json] <old definition> <new definition>",
+	Description: "Lists the changes between two definitions, failing on breaking changes.",
+}
+
+func init() {
+	diffCommand.Run = runDiff
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(diffCommand, stderr)
+	asJSON := flags.Bool("json", false, "print the change plan as JSON")
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 2 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	old, _, err := loadDefinitions(positional[0])
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	next, _, err := loadDefinitions(positional[1])
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	plan := diff.Compare(old, next)
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(plan); err != nil {
+			fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+			return exitError
+		}
+	} else {
+		for _, change := range plan.Changes {
+			fmt.Fprintln(stdout, change)
+		}
+	}
+
+	if plan.IsBreaking() {
+		return exitBreaking
+	}
+
+	return exitOK
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/builder"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy"
+)
+
+const (
+	formatDir   = "dir"
+	formatZip   = "zip"
+	formatTarGz = "tar.gz"
+)
+
+var generateCommand = &command{
+	Name:        "generate",
+	Usage:       "generate <definition> -o <path> [-format dir|zip|tar.gz] [-id <id>] [-merge | -on-conflict fail|side-file|overwrite|merge] [-templates <dir>]... [-seed <n>] [-offline] [-steps <names>] [-skip-steps <names>] [-v]",
+	Description: "Generates the project described by a definition file.",
+}
+
+func init() {
+	generateCommand.Run = runGenerate
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(generateCommand, stderr)
+	output := flags.String("o", ".", "output directory, or archive file for the zip and tar.gz formats (\"-\" for stdout)")
+	format := flags.String("format", formatDir, "output format: dir, zip or tar.gz")
+	id := flags.String("id", "", "project id, used as an intermediate folder inside the output")
+	onConflict := flags.String("on-conflict", string(builder.ConflictFail), "what to do with generated files changed by hand: fail, side-file, overwrite or merge")
+	mergeMode := flags.Bool("merge", false, "three-way merge generated files changed by hand, same as -on-conflict merge")
+	var templateDirs stringList
+	flags.Var(&templateDirs, "templates", "template override directory, searched before the built-in templates (repeatable)")
+	seed := flags.Int64("seed", 0, "seed of the example values used by the generated tests")
+	offline := flags.Bool("offline", false, "resolve the dependencies without network access: pinned go.mod and go.sum files for Go, the npm cache for TypeScript")
+	steps := flags.String("steps", "", "comma separated post build steps to run, in order, instead of the default ones")
+	skipSteps := flags.String("skip-steps", "", "comma separated post build steps not to run, e.g. test")
+	verbose := flags.Bool("v", false, "print the written files and the post build steps, with their output")
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if *format != formatDir && *format != formatZip && *format != formatTarGz {
+		fmt.Fprintf(stderr, "fancybuild: unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	policy := builder.ConflictPolicy(*onConflict)
+
+	if *mergeMode {
+		explicit := false
+
+		flags.Visit(func(f *flag.Flag) {
+			explicit = explicit || f.Name == "on-conflict"
+		})
+
+		// -merge is an alias, it does not override another policy
+		if explicit && policy != builder.ConflictMerge {
+			fmt.Fprintf(stderr, "fancybuild: -merge cannot be used with -on-conflict %s\n", *onConflict)
+			return exitUsage
+		}
+
+		policy = builder.ConflictMerge
+	}
+
+	switch policy {
+	case builder.ConflictFail, builder.ConflictSideFile, builder.ConflictOverwrite, builder.ConflictMerge:
+	default:
+		fmt.Fprintf(stderr, "fancybuild: unknown conflict policy %q\n", *onConflict)
+		return exitUsage
+	}
+
+	definitions, code := loadValidDefinitions(positional[0], stderr)
+
+	if code != exitOK {
+		return code
+	}
+
+	definitions.Id = *id
+	stgy, err := strategy.NewStrategy(definitions, &entities.Options{
+		TemplateDirs: templateDirs,
+		Seed:         *seed,
+		Offline:      *offline,
+		Steps:        splitList(*steps),
+		SkipSteps:    splitList(*skipSteps),
+	})
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	out, err := openOutput(*format, *output, stdout)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	options := &builder.Options{OnConflict: policy}
+
+	if *verbose {
+		options.OnEvent = func(event builder.Event) {
+			printEvent(stderr, event)
+		}
+	}
+
+	b := builder.NewBuilder(options)
+	report, err := b.Build(definitions, stgy, out)
+
+	if conflictErr, ok := err.(*builder.ConflictError); ok {
+		fmt.Fprintln(stderr, "fancybuild: refusing to overwrite files changed since the last build:")
+
+		for _, file := range conflictErr.Files {
+			fmt.Fprintf(stderr, "  %s\n", file)
+		}
+
+		fmt.Fprintln(stderr, "use -merge, -on-conflict side-file or -on-conflict overwrite to build anyway")
+		return exitError
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: building project: %s\n", err)
+		return exitError
+	}
+
+	for _, file := range report.Conflicts {
+		if policy == builder.ConflictMerge {
+			fmt.Fprintf(stderr, "merged with conflicts, fix the conflict markers: %s\n", file)
+		} else {
+			fmt.Fprintf(stderr, "changed since the last build, new version written to %s%s\n", file, builder.SideFileSuffix)
+		}
+	}
+
+	for _, file := range report.Merged {
+		fmt.Fprintf(stderr, "merged: %s\n", file)
+	}
+
+	for _, file := range report.Stale {
+		fmt.Fprintf(stderr, "not generated anymore, can be deleted: %s\n", file)
+	}
+
+	switch {
+	case *format == formatDir:
+		fmt.Fprintf(stdout, "generated %s\n", filepath.Join(*output, definitions.Id, definitions.App.Name))
+	case *output != "-":
+		fmt.Fprintf(stdout, "generated %s\n", *output)
+	}
+
+	return exitOK
+}
+
+func printEvent(w io.Writer, event builder.Event) {
+	switch e := event.(type) {
+	case builder.FileWritten:
+		fmt.Fprintf(w, "wrote %s\n", e.Output)
+	case builder.StepStarted:
+		fmt.Fprintf(w, "running %s\n", e.Name)
+	case builder.StepFinished:
+		for _, line := range strings.Split(strings.TrimRight(e.Output, "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+
+		status := "ok"
+
+		if e.Err != nil {
+			status = "failed"
+		}
+
+		fmt.Fprintf(w, "%s %s in %s\n", e.Name, status, e.Duration.Round(time.Millisecond))
+	}
+}
+
+func openOutput(format string, path string, stdout io.Writer) (builder.Output, error) {
+	if format == formatDir {
+		return builder.NewDirOutput(path), nil
+	}
+
+	if path == "-" {
+		return archive(format, stdout), nil
+	}
+
+	f, err := os.Create(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+
+	return &fileOutput{archive(format, f), f}, nil
+}
+
+func archive(format string, w io.Writer) builder.Output {
+	if format == formatZip {
+		return builder.NewZipOutput(w)
+	}
+
+	return builder.NewTarGzOutput(w)
+}
+
+// fileOutput closes the archive file after the archive itself
+type fileOutput struct {
+	builder.Output
+	file *os.File
+}
+
+func (f *fileOutput) Close() error {
+	err := f.Output.Close()
+
+	if err != nil {
+		f.file.Close()
+		return err
+	}
+
+	return f.file.Close()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy"
+)
+
+var inspectCommand = &command{
+	Name:        "inspect",
+	Usage:       "inspect [-json] [-templates <dir>]... [-offline] <definition>",
+	Description: "Lists the entities, actions, routes and files of a definition.",
+}
+
+func init() {
+	inspectCommand.Run = runInspect
+}
+
+type inspection struct {
+	Entities []*inspectedEntity `json:"entities"`
+	Routes   []*inspectedRoute  `json:"routes"`
+	Files    []*inspectedFile   `json:"files"`
+}
+
+type inspectedEntity struct {
+	Name      string   `json:"name"`
+	Persisted bool     `json:"persisted"`
+	Nested    bool     `json:"nested"`
+	Actions   []string `json:"actions"`
+}
+
+type inspectedRoute struct {
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	Authenticated bool   `json:"authenticated"`
+}
+
+type inspectedFile struct {
+	Path     string `json:"path"`
+	Template string `json:"template"`
+}
+
+func runInspect(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(inspectCommand, stderr)
+	asJSON := flags.Bool("json", false, "print the inspection as JSON")
+	var templateDirs stringList
+	flags.Var(&templateDirs, "templates", "template override directory, searched before the built-in templates (repeatable)")
+	offline := flags.Bool("offline", false, "include the pinned go.mod and go.sum files of an offline generation")
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	definitions, code := loadValidDefinitions(positional[0], stderr)
+
+	if code != exitOK {
+		return code
+	}
+
+	stgy, err := strategy.NewStrategy(definitions, &entities.Options{
+		TemplateDirs: templateDirs,
+		Offline:      *offline,
+	})
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	fileMap, err := stgy.BuildFileMap()
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	result := inspect(definitions, fileMap)
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+
+		if err != nil {
+			fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+			return exitError
+		}
+
+		return exitOK
+	}
+
+	printInspection(stdout, result)
+	return exitOK
+}
+
+func inspect(definitions *entities.Definitions, fileMap map[string]*entities.File) *inspection {
+	result := &inspection{
+		Entities: make([]*inspectedEntity, 0),
+		Routes:   []*inspectedRoute{{Method: "GET", Path: "/health"}},
+		Files:    make([]*inspectedFile, 0),
+	}
+
+	for _, entity := range definitions.App.Entities {
+		actions := make([]string, 0)
+
+		for _, action := range entity.Actions {
+			actions = append(actions, action.Type)
+		}
+
+		result.Entities = append(result.Entities, &inspectedEntity{
+			Name:      entity.Name,
+			Persisted: entity.Persisted,
+			Nested:    entity.IsNested(),
+			Actions:   actions,
+		})
+
+		if !entity.HasController() {
+			continue
+		}
+
+		for _, action := range entity.Actions {
+			methods := []string{action.HTTPMethod()}
+
+			if action.IsUpdate() {
+				methods = append(methods, "PATCH")
+			}
+
+			for _, method := range methods {
+				result.Routes = append(result.Routes, &inspectedRoute{
+					Method:        method,
+					Path:          action.Path(),
+					Authenticated: action.Authenticated || entity.IsAuthenticated(),
+				})
+			}
+		}
+	}
+
+	if definitions.HasAuthentication() {
+		result.Routes = append(result.Routes,
+			&inspectedRoute{Method: "POST", Path: "/v1/auth/signin"},
+			&inspectedRoute{Method: "POST", Path: "/v1/auth/signout", Authenticated: true},
+			&inspectedRoute{Method: "GET", Path: "/v1/auth/me", Authenticated: true},
+		)
+	}
+
+	for _, file := range fileMap {
+		result.Files = append(result.Files, &inspectedFile{
+			Path:     file.FinalPath,
+			Template: file.TemplatePath,
+		})
+	}
+
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].Path < result.Files[j].Path
+	})
+
+	return result
+}
+
+func printInspection(w io.Writer, result *inspection) {
+	fmt.Fprintln(w, "Entities:")
+
+	for _, entity := range result.Entities {
+		flags := ""
+
+		if !entity.Persisted {
+			flags = " (not persisted)"
+		} else if entity.Nested {
+			flags = " (nested)"
+		}
+
+		fmt.Fprintf(w, "  %s%s\n", entity.Name, flags)
+
+		for _, action := range entity.Actions {
+			fmt.Fprintf(w, "    - %s\n", action)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Routes:")
+
+	for _, route := range result.Routes {
+		auth := ""
+
+		if route.Authenticated {
+			auth = " (authenticated)"
+		}
+
+		fmt.Fprintf(w, "  %-7s %s%s\n", route.Method, route.Path, auth)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Files:")
+
+	for _, file := range result.Files {
+		fmt.Fprintf(w, "  %-40s %s\n", file.Path, file.Template)
+	}
+}
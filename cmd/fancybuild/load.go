@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy"
+)
+
+// loadDefinitions reads and parses a JSON, YAML or TOML definitions file.
+// Validation is left to the caller, so it can decide how to report the errors,
+// locating them with the returned positions.
+func loadDefinitions(path string) (*entities.Definitions, reader.Positions, error) {
+	var definitions entities.Definitions
+	positions, err := reader.NewReader().ReadFile(path, &definitions)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading definition %s: %w", path, err)
+	}
+
+	if definitions.App == nil {
+		return nil, nil, fmt.Errorf("parsing definition %s: missing \"app\" object", path)
+	}
+
+	return &definitions, positions, nil
+}
+
+// loadValidDefinitions reads a definitions file and validates it, printing the
+// validation errors to w. The returned exit code is exitOK when the definition
+// can be used.
+func loadValidDefinitions(path string, w io.Writer) (*entities.Definitions, int) {
+	definitions, positions, err := loadDefinitions(path)
+
+	if err != nil {
+		fmt.Fprintf(w, "fancybuild: %s\n", err)
+		return nil, exitError
+	}
+
+	validationErr := validate(definitions, positions)
+
+	if validationErr != nil {
+		printValidationError(w, path, validationErr)
+		return nil, exitInvalid
+	}
+
+	return definitions, exitOK
+}
+
+// validate checks the definitions and that a registered strategy generates
+// their stack, so the definitions validate accepts can be generated
+func validate(definitions *entities.Definitions, positions reader.Positions) *reader.ValidationError {
+	validationErr := reader.NewReader().Validate(definitions, positions)
+
+	if validationErr != nil {
+		return validationErr
+	}
+
+	stack := definitions.App.Stack
+	err := strategy.Supported(stack)
+
+	if err == nil {
+		return nil
+	}
+
+	// Locate the part of the stack no strategy supports
+	path, value := "app.stack.language", stack.Language
+	databases := make([]string, 0)
+
+	for _, registered := range strategy.Registered() {
+		if registered.Language == stack.Language {
+			databases = append(databases, registered.Database)
+		}
+	}
+
+	switch {
+	case len(databases) > 0 && !contains(databases, stack.Database):
+		path, value = "app.stack.database", stack.Database
+	case len(databases) > 0:
+		path, value = "app.stack.framework", stack.Framework
+	}
+
+	position := positions.Lookup(path)
+
+	return &reader.ValidationError{
+		Message: reader.ErrorMessage,
+		Errors: []*reader.FieldError{{
+			Field:   path,
+			Tag:     "stack",
+			Value:   value,
+			Path:    path,
+			File:    position.File,
+			Line:    position.Line,
+			Column:  position.Column,
+			Message: err.Error(),
+		}},
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printValidationError prints the errors compiler-style, e.g.
+// "app.yaml:12:15: app.relationships[0].item1: unknown entity "usr", did you mean "user"?"
+func printValidationError(w io.Writer, path string, validationErr *reader.ValidationError) {
+	for _, fieldErr := range validationErr.Errors {
+		location := path
+
+		if fieldErr.File != "" {
+			location = fieldErr.File
+		}
+
+		if fieldErr.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", location, fieldErr.Line, fieldErr.Column)
+		}
+
+		message := fieldErr.Message
+
+		if fieldErr.Suggestion != "" {
+			message = fmt.Sprintf("%s, did you mean %q?", message, fieldErr.Suggestion)
+		}
+
+		fmt.Fprintf(w, "%s: %s: %s\n", location, fieldErr.Path, message)
+	}
+}
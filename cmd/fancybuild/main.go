@@ -0,0 +1,164 @@
+// Command fancybuild generates web applications from a definitions file.
+//
+// Usage:
+//
+//	fancybuild generate <definition> -o <dir>
+//	fancybuild validate <definition>
+//	fancybuild inspect <definition>
+//	fancybuild stacks
+//	fancybuild schema
+//	fancybuild upgrade <definition>
+//	fancybuild diff <old definition> <new definition>
+//
+// Exit codes are stable so the tool can be driven from scripts and Makefiles:
+// 0 on success, 1 on runtime errors, 2 on invalid usage, 3 when the
+// definition does not pass validation and 4 when diff finds breaking changes.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	exitOK       = 0 // Command finished successfully
+	exitError    = 1 // Reading, rendering or writing failed
+	exitUsage    = 2 // Invalid command line
+	exitInvalid  = 3 // The definition did not pass validation
+	exitBreaking = 4 // The definitions differ by breaking changes
+)
+
+type command struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = []*command{
+	generateCommand,
+	validateCommand,
+	inspectCommand,
+	stacksCommand,
+	schemaCommand,
+	upgradeCommand,
+	diffCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.Name == args[0] {
+			return cmd.Run(args[1:], stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "fancybuild: unknown command %q\n\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: fancybuild <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Description)
+	}
+}
+
+// parseArgs parses flags that may appear before or after the positional
+// arguments, e.g. "generate app.json -o out" and "generate -o out app.json".
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+
+	for {
+		err := flags.Parse(args)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseError returns the exit code of an error of parseArgs. The flag set
+// already printed the error and the usage, and the help asked with -h is not
+// a failure.
+func parseError(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	return exitUsage
+}
+
+// stringList is a flag that can be repeated, e.g. "-templates a -templates b"
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// splitList splits a comma separated flag value, e.g. "build,test"
+func splitList(value string) []string {
+	result := make([]string, 0)
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+func newFlagSet(cmd *command, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: fancybuild %s\n\n%s\n", cmd.Usage, cmd.Description)
+
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+
+		if hasFlags {
+			fmt.Fprintln(stderr)
+			fmt.Fprintln(stderr, "Flags:")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
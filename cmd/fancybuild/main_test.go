@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type runTestCase struct {
+	Description  string
+	Args         []string
+	ExpectedCode int
+	ExpectedOut  string
+}
+
+func writeDefinition(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "definition.json")
+	err := os.WriteFile(path, []byte(content), 0644)
+
+	if err != nil {
+		t.Fatalf("writing definition: %s", err)
+	}
+
+	return path
+}
+
+func TestRun(t *testing.T) {
+	invalid := writeDefinition(t, `{"version": "1.0.0", "app": {"name": "x", "entities": []}}`)
+	malformed := writeDefinition(t, `{"app": `)
+	withEntity := writeDefinition(t, `{"version": "1.0.0", "app": {"name": "xyz", "entities": [{"name": "task", "persisted": true}]}}`)
+	unknownFramework := writeDefinition(t, `{"version": "1.0.0", "app": {"name": "xyz", "stack": {"language": "go", "framework": "gin", "database": "mongodb"}, "entities": [{"name": "task", "persisted": true, "actions": [{"type": "getAll"}]}]}}`)
+
+	testCases := []*runTestCase{
+		{
+			Description:  "no command",
+			Args:         []string{},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "unknown command",
+			Args:         []string{"build"},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "validate without definition",
+			Args:         []string{"validate"},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "help of a command",
+			Args:         []string{"generate", "-h"},
+			ExpectedCode: exitOK,
+		},
+		{
+			Description:  "unknown flag",
+			Args:         []string{"validate", "-strict", "../../_examples/blog.json"},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "validate valid definition",
+			Args:         []string{"validate", "../../_examples/blog.json"},
+			ExpectedCode: exitOK,
+			ExpectedOut:  "ok",
+		},
+		{
+			Description:  "validate invalid definition",
+			Args:         []string{"validate", invalid},
+			ExpectedCode: exitInvalid,
+		},
+		{
+			Description:  "validate invalid definition as json",
+			Args:         []string{"validate", invalid, "-json"},
+			ExpectedCode: exitInvalid,
+			ExpectedOut:  `"tag": "min"`,
+		},
+		{
+			Description:  "validate unknown framework",
+			Args:         []string{"validate", unknownFramework, "-json"},
+			ExpectedCode: exitInvalid,
+			ExpectedOut:  `"field": "app.stack.framework"`,
+		},
+		{
+			Description:  "generate unknown framework",
+			Args:         []string{"generate", unknownFramework, "-o", t.TempDir()},
+			ExpectedCode: exitInvalid,
+		},
+		{
+			Description:  "validate malformed definition",
+			Args:         []string{"validate", malformed},
+			ExpectedCode: exitError,
+		},
+		{
+			Description:  "validate missing file",
+			Args:         []string{"validate", "i-dont-exist.json"},
+			ExpectedCode: exitError,
+		},
+		{
+			Description:  "generate invalid definition",
+			Args:         []string{"generate", invalid, "-o", t.TempDir()},
+			ExpectedCode: exitInvalid,
+		},
+		{
+			Description:  "generate with merge and another conflict policy",
+			Args:         []string{"generate", invalid, "-o", t.TempDir(), "-merge", "-on-conflict", "side-file"},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "generate offline with selected steps",
+			Args:         []string{"generate", "../../_examples/blog.json", "-o", t.TempDir(), "-offline", "-steps", "format", "-v"},
+			ExpectedCode: exitOK,
+			ExpectedOut:  "generated",
+		},
+		{
+			Description:  "generate with unknown step",
+			Args:         []string{"generate", "../../_examples/blog.json", "-o", t.TempDir(), "-skip-steps", "lint"},
+			ExpectedCode: exitError,
+		},
+		{
+			Description:  "diff without changes",
+			Args:         []string{"diff", invalid, invalid},
+			ExpectedCode: exitOK,
+		},
+		{
+			Description:  "diff with breaking changes",
+			Args:         []string{"diff", withEntity, invalid},
+			ExpectedCode: exitBreaking,
+			ExpectedOut:  "removed entity task",
+		},
+		{
+			Description:  "diff missing definition",
+			Args:         []string{"diff", invalid},
+			ExpectedCode: exitUsage,
+		},
+		{
+			Description:  "upgrade current definition",
+			Args:         []string{"upgrade", withEntity},
+			ExpectedCode: exitOK,
+			ExpectedOut:  "already at version",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(testCase.Args, &stdout, &stderr)
+
+			if code != testCase.ExpectedCode {
+				t.Errorf("expected exit code %d, got %d, stderr: %s", testCase.ExpectedCode, code, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), testCase.ExpectedOut) {
+				t.Errorf("expected output to contain %q, got %q", testCase.ExpectedOut, stdout.String())
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	// Definitions without a version are written with the current one
+	const outdated = `{"app": {"name": "todo"}}`
+
+	t.Run("writes the upgraded file next to the definition", func(t *testing.T) {
+		path := writeDefinition(t, outdated)
+		var stdout, stderr bytes.Buffer
+
+		if code := run([]string{"upgrade", path}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr.String())
+		}
+
+		original, _ := os.ReadFile(path)
+
+		if string(original) != outdated {
+			t.Errorf("expected the definition to be untouched, got %s", original)
+		}
+
+		upgraded, err := os.ReadFile(path + ".upgraded")
+
+		if err != nil || !strings.Contains(string(upgraded), `"version"`) {
+			t.Errorf("expected an upgraded definition, got %s (%v)", upgraded, err)
+		}
+	})
+
+	t.Run("overwrites the definition with -w", func(t *testing.T) {
+		path := writeDefinition(t, outdated)
+		var stdout, stderr bytes.Buffer
+
+		if code := run([]string{"upgrade", "-w", path}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr.String())
+		}
+
+		upgraded, _ := os.ReadFile(path)
+
+		if !strings.Contains(string(upgraded), `"version"`) {
+			t.Errorf("expected the definition to be upgraded, got %s", upgraded)
+		}
+
+		if _, err := os.Stat(path + ".upgraded"); !os.IsNotExist(err) {
+			t.Errorf("expected no %s.upgraded file", path)
+		}
+	})
+}
+
+func TestHelp(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"generate", "-h"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+
+	if count := strings.Count(stderr.String(), "Usage: fancybuild generate"); count != 1 {
+		t.Errorf("expected the usage once, got it %d times:\n%s", count, stderr.String())
+	}
+}
+
+func TestGenerateMerge(t *testing.T) {
+	blog, err := os.ReadFile("../../_examples/blog.json")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definition := writeDefinition(t, string(blog))
+	output := t.TempDir()
+	service := filepath.Join(output, "blog", "blog", "pkg", "post", "service.go")
+	args := []string{"generate", definition, "-o", output, "-id", "blog", "-offline", "-steps", "format", "-merge"}
+
+	generate := func(t *testing.T) string {
+		var stdout, stderr bytes.Buffer
+
+		if code := run(args, &stdout, &stderr); code != exitOK {
+			t.Fatalf("expected exit code %d, got %d, stderr: %s", exitOK, code, stderr.String())
+		}
+
+		return stderr.String()
+	}
+
+	// Changes the first validation of the name of a post, in a gofmt aligned struct
+	edit := func(t *testing.T, from string, to string) {
+		content, err := os.ReadFile(service)
+
+		if err != nil || !strings.Contains(string(content), from) {
+			t.Fatalf("expected %s to contain %q (%v)", service, from, err)
+		}
+
+		err = os.WriteFile(service, []byte(strings.Replace(string(content), from, to, 1)), 0644)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generate(t)
+
+	t.Run("keeps the user changes of a definition that did not change", func(t *testing.T) {
+		edit(t, `validate:"omitempty,min=3"`, `validate:"omitempty,min=4"`)
+
+		if out := generate(t); !strings.Contains(out, "merged: pkg/post/service.go") {
+			t.Errorf("expected the service to be merged, got %q", out)
+		}
+
+		content, _ := os.ReadFile(service)
+
+		if !strings.Contains(string(content), `validate:"omitempty,min=4"`) || strings.Contains(string(content), "<<<<<<<") {
+			t.Errorf("expected the user change without conflicts, got:\n%s", content)
+		}
+	})
+
+	t.Run("leaves the conflicts to the user", func(t *testing.T) {
+		changed := strings.Replace(string(blog), `"value": "3"`, `"value": "5"`, 1)
+
+		if err := os.WriteFile(definition, []byte(changed), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if out := generate(t); !strings.Contains(out, "merged with conflicts, fix the conflict markers: pkg/post/service.go") {
+			t.Errorf("expected the service to be merged with conflicts, got %q", out)
+		}
+
+		content, _ := os.ReadFile(service)
+
+		if !strings.Contains(string(content), "<<<<<<< current") {
+			t.Errorf("expected the conflict markers, left alone by the format step, got:\n%s", content)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/schema"
+)
+
+var schemaCommand = &command{
+	Name:        "schema",
+	Usage:       "schema",
+	Description: "Prints the JSON Schema of the definitions format.",
+}
+
+func init() {
+	schemaCommand.Run = runSchema
+}
+
+func runSchema(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(schemaCommand, stderr)
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	data, err := schema.JSON()
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: generating schema: %s\n", err)
+		return exitError
+	}
+
+	stdout.Write(data)
+	return exitOK
+}
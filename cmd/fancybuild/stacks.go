@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy"
+)
+
+var stacksCommand = &command{
+	Name:        "stacks",
+	Usage:       "stacks",
+	Description: "Lists the supported language/framework/database stacks.",
+}
+
+func init() {
+	stacksCommand.Run = runStacks
+}
+
+func runStacks(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(stacksCommand, stderr)
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	fmt.Fprintf(stdout, "%-12s %-12s %s\n", "LANGUAGE", "FRAMEWORK", "DATABASE")
+
+	for _, stack := range strategy.Registered() {
+		fmt.Fprintf(stdout, "%-12s %-12s %s\n", stack.Language, stack.Framework, stack.Database)
+	}
+
+	return exitOK
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+)
+
+var upgradeCommand = &command{
+	Name:        "upgrade",
+	Usage:       "upgrade [-w] <definition>",
+	Description: "Writes a definition file in the current definitions format version to <definition>.upgraded, or over the file with -w. YAML and TOML comments are not kept.",
+}
+
+func init() {
+	upgradeCommand.Run = runUpgrade
+}
+
+func runUpgrade(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(upgradeCommand, stderr)
+	overwrite := flags.Bool("w", false, "overwrite the definition file instead of writing <definition>.upgraded")
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	path := positional[0]
+	info, err := os.Stat(path)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: reading definition: %s\n", err)
+		return exitError
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: reading definition: %s\n", err)
+		return exitError
+	}
+
+	result, changed, err := reader.Upgrade(data, reader.FileFormat(path, data))
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: upgrading definition %s: %s\n", path, err)
+		return exitError
+	}
+
+	if !changed {
+		fmt.Fprintf(stdout, "%s: already at version %s\n", path, reader.CurrentVersion)
+		return exitOK
+	}
+
+	// The upgraded file is written from the decoded document, so the comments
+	// of the original file are lost. It is kept unless explicitly overwritten.
+	target := path + ".upgraded"
+
+	if *overwrite {
+		target = path
+	}
+
+	err = os.WriteFile(target, result, info.Mode().Perm())
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: writing definition: %s\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "%s: upgraded to version %s\n", target, reader.CurrentVersion)
+	return exitOK
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+var validateCommand = &command{
+	Name:        "validate",
+	Usage:       "validate [-json] <definition>",
+	Description: "Checks a definition file and prints its validation errors.",
+}
+
+func init() {
+	validateCommand.Run = runValidate
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	flags := newFlagSet(validateCommand, stderr)
+	asJSON := flags.Bool("json", false, "print the validation result as JSON")
+	positional, err := parseArgs(flags, args)
+
+	if err != nil {
+		return parseError(err)
+	}
+
+	if len(positional) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	path := positional[0]
+	definitions, positions, err := loadDefinitions(path)
+
+	if err != nil {
+		fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+		return exitError
+	}
+
+	validationErr := validate(definitions, positions)
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		if validationErr == nil {
+			err = encoder.Encode(map[string]interface{}{"valid": true})
+		} else {
+			err = encoder.Encode(validationErr)
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "fancybuild: %s\n", err)
+			return exitError
+		}
+	}
+
+	if validationErr != nil {
+		if !*asJSON {
+			printValidationError(stderr, path, validationErr)
+		}
+		return exitInvalid
+	}
+
+	if !*asJSON {
+		fmt.Fprintf(stdout, "%s: ok\n", path)
+	}
+
+	return exitOK
+}
@@ -2,4 +2,10 @@ module github.com/danilo-medeiros/fancybuild/engine
 
 go 1.16
 
-require github.com/go-playground/validator/v10 v10.9.0 // indirect
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/go-playground/validator/v10 v10.9.0
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,109 @@
+package merge
+
+// diff - Finds the hunks that turn a into b, sorted by position, using the
+// Myers shortest edit script algorithm
+func diff(a, b []string) []hunk {
+	n, m := len(a), len(b)
+	bound := n + m
+	offset := bound + 1
+	v := make([]int, 2*bound+2)
+
+	// The furthest x of the diagonals -d to d after each round d, only the
+	// diagonals a round touches are kept
+	trace := make([][]int, 0)
+
+	for d := 0; d <= bound; d++ {
+		done := false
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+
+		round := make([]int, 2*d+1)
+		copy(round, v[offset-d:offset+d+1])
+		trace = append(trace, round)
+
+		if done {
+			break
+		}
+	}
+
+	// Walk the trace backwards collecting the lines that match on both sides
+	type match struct{ a, b int }
+	matches := make([]match, 0)
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		previous := trace[d-1]
+		furthest := func(k int) int {
+			return previous[k+d-1]
+		}
+
+		k := x - y
+		var prevK int
+
+		if k == -d || (k != d && furthest(k-1) < furthest(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := furthest(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, match{x, y})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	// The lines matched before the first edit
+	for x > 0 && y > 0 {
+		x--
+		y--
+		matches = append(matches, match{x, y})
+	}
+
+	// The lines between two consecutive matches were changed
+	hunks := make([]hunk, 0)
+	lastA, lastB := 0, 0
+
+	for i := len(matches) - 1; i >= -1; i-- {
+		nextA, nextB := n, m
+
+		if i >= 0 {
+			nextA, nextB = matches[i].a, matches[i].b
+		}
+
+		if nextA > lastA || nextB > lastB {
+			hunks = append(hunks, hunk{lastA, nextA, lastB, nextB})
+		}
+
+		lastA, lastB = nextA+1, nextB+1
+	}
+
+	return hunks
+}
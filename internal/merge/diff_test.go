@@ -0,0 +1,75 @@
+package merge
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Length of the longest common subsequence of a and b
+func commonLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] > lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+func randomLines(random *rand.Rand) []string {
+	lines := make([]string, random.Intn(30))
+
+	for i := range lines {
+		lines[i] = string(rune('a' + random.Intn(4)))
+	}
+
+	return lines
+}
+
+func TestDiff(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for attempt := 0; attempt < 500; attempt++ {
+		a, b := randomLines(random), randomLines(random)
+		hunks := diff(a, b)
+		result := make([]string, 0, len(b))
+		last, unchanged := 0, 0
+
+		for _, h := range hunks {
+			result = append(result, a[last:h.aStart]...)
+			result = append(result, b[h.bStart:h.bEnd]...)
+			unchanged += h.aStart - last
+			last = h.aEnd
+		}
+
+		result = append(result, a[last:]...)
+		unchanged += len(a) - last
+
+		if len(result) != len(b) {
+			t.Fatalf("diff of %q and %q: applying the hunks gave %q", a, b, result)
+		}
+
+		for i := range b {
+			if result[i] != b[i] {
+				t.Fatalf("diff of %q and %q: applying the hunks gave %q", a, b, result)
+			}
+		}
+
+		if expected := commonLength(a, b); unchanged != expected {
+			t.Fatalf("diff of %q and %q kept %d lines, the shortest edit keeps %d", a, b, unchanged, expected)
+		}
+	}
+}
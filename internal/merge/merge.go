@@ -0,0 +1,140 @@
+// Package merge implements a line based three-way merge, used to regenerate
+// files that were changed by hand.
+package merge
+
+import "strings"
+
+const (
+	MarkerOurs   = "<<<<<<< "
+	MarkerSep    = "======="
+	MarkerTheirs = ">>>>>>> "
+)
+
+// Labels - Names written after the conflict markers
+type Labels struct {
+	Ours   string
+	Theirs string
+}
+
+// A block of lines of a, from aStart to aEnd, replaced by the lines of b from bStart to bEnd
+type hunk struct {
+	aStart, aEnd int
+	bStart, bEnd int
+}
+
+// Merge - Applies the changes from base to ours and from base to theirs at the
+// same time. When both sides change the same lines differently, both versions
+// are written between conflict markers. Returns whether there were conflicts.
+func Merge(base, ours, theirs string, labels Labels) (string, bool) {
+	baseLines := strings.Split(base, "\n")
+	oursLines := strings.Split(ours, "\n")
+	theirsLines := strings.Split(theirs, "\n")
+
+	oursHunks := diff(baseLines, oursLines)
+	theirsHunks := diff(baseLines, theirsLines)
+
+	result := make([]string, 0, len(oursLines))
+	conflict := false
+	pos := 0
+	i, j := 0, 0
+
+	for i < len(oursHunks) || j < len(theirsHunks) {
+		// Group the hunks of both sides that touch the same lines of base,
+		// starting with the one that comes first
+		var groupOurs, groupTheirs []hunk
+		var start, end int
+
+		if j >= len(theirsHunks) || (i < len(oursHunks) && oursHunks[i].aStart <= theirsHunks[j].aStart) {
+			start, end = oursHunks[i].aStart, oursHunks[i].aEnd
+			groupOurs = append(groupOurs, oursHunks[i])
+			i++
+		} else {
+			start, end = theirsHunks[j].aStart, theirsHunks[j].aEnd
+			groupTheirs = append(groupTheirs, theirsHunks[j])
+			j++
+		}
+
+		for {
+			if i < len(oursHunks) && overlaps(oursHunks[i], start, end) {
+				if oursHunks[i].aEnd > end {
+					end = oursHunks[i].aEnd
+				}
+
+				groupOurs = append(groupOurs, oursHunks[i])
+				i++
+				continue
+			}
+
+			if j < len(theirsHunks) && overlaps(theirsHunks[j], start, end) {
+				if theirsHunks[j].aEnd > end {
+					end = theirsHunks[j].aEnd
+				}
+
+				groupTheirs = append(groupTheirs, theirsHunks[j])
+				j++
+				continue
+			}
+
+			break
+		}
+
+		result = append(result, baseLines[pos:start]...)
+		pos = end
+
+		oursText := apply(baseLines, oursLines, groupOurs, start, end)
+		theirsText := apply(baseLines, theirsLines, groupTheirs, start, end)
+
+		switch {
+		case len(groupOurs) == 0:
+			result = append(result, theirsText...)
+		case len(groupTheirs) == 0:
+			result = append(result, oursText...)
+		case equal(oursText, theirsText):
+			result = append(result, oursText...)
+		default:
+			conflict = true
+			result = append(result, MarkerOurs+labels.Ours)
+			result = append(result, oursText...)
+			result = append(result, MarkerSep)
+			result = append(result, theirsText...)
+			result = append(result, MarkerTheirs+labels.Theirs)
+		}
+	}
+
+	result = append(result, baseLines[pos:]...)
+	return strings.Join(result, "\n"), conflict
+}
+
+// Checks if a hunk touches the base lines from start to end. Changes at the
+// position of an insertion also overlap with it.
+func overlaps(h hunk, start, end int) bool {
+	return h.aStart < end || (start == end && h.aStart == start)
+}
+
+// Returns the lines of one side for the base lines from start to end
+func apply(base, side []string, hunks []hunk, start, end int) []string {
+	result := make([]string, 0)
+	pos := start
+
+	for _, h := range hunks {
+		result = append(result, base[pos:h.aStart]...)
+		result = append(result, side[h.bStart:h.bEnd]...)
+		pos = h.aEnd
+	}
+
+	return append(result, base[pos:end]...)
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
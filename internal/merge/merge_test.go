@@ -0,0 +1,99 @@
+package merge
+
+import "testing"
+
+type mergeTestCase struct {
+	Description      string
+	Base             string
+	Ours             string
+	Theirs           string
+	Expected         string
+	ExpectedConflict bool
+}
+
+var labels = Labels{Ours: "current", Theirs: "generated"}
+
+func TestMerge(t *testing.T) {
+	testCases := []*mergeTestCase{
+		{
+			Description: "no changes",
+			Base:        "a\nb\nc",
+			Ours:        "a\nb\nc",
+			Theirs:      "a\nb\nc",
+			Expected:    "a\nb\nc",
+		},
+		{
+			Description: "changes only on ours",
+			Base:        "a\nb\nc",
+			Ours:        "a\nB\nc\nd",
+			Theirs:      "a\nb\nc",
+			Expected:    "a\nB\nc\nd",
+		},
+		{
+			Description: "changes only on theirs",
+			Base:        "a\nb\nc",
+			Ours:        "a\nb\nc",
+			Theirs:      "x\na\nc",
+			Expected:    "x\na\nc",
+		},
+		{
+			Description: "changes on different lines",
+			Base:        "a\nb\nc\nd\ne\nf",
+			Ours:        "a\nB\nc\nd\ne\nf",
+			Theirs:      "a\nb\nc\nd\nE\nf\ng",
+			Expected:    "a\nB\nc\nd\nE\nf\ng",
+		},
+		{
+			Description: "changes far apart, first on theirs",
+			Base:        "a\nb\nc\nd\ne\nf\ng\nh",
+			Ours:        "a\nb\nc\nd\ne\nf\nG\nh",
+			Theirs:      "A\nb\nc\nd\ne\nf\ng\nh",
+			Expected:    "A\nb\nc\nd\ne\nf\nG\nh",
+		},
+		{
+			Description: "same change on both sides",
+			Base:        "a\nb\nc",
+			Ours:        "a\nB\nc",
+			Theirs:      "a\nB\nc",
+			Expected:    "a\nB\nc",
+		},
+		{
+			Description:      "different changes on the same line",
+			Base:             "a\nb\nc",
+			Ours:             "a\nours\nc",
+			Theirs:           "a\ntheirs\nc",
+			Expected:         "a\n<<<<<<< current\nours\n=======\ntheirs\n>>>>>>> generated\nc",
+			ExpectedConflict: true,
+		},
+		{
+			Description:      "different insertions at the same position",
+			Base:             "a\nb",
+			Ours:             "a\nx\nb",
+			Theirs:           "a\ny\nb",
+			Expected:         "a\n<<<<<<< current\nx\n=======\ny\n>>>>>>> generated\nb",
+			ExpectedConflict: true,
+		},
+		{
+			Description:      "empty base",
+			Base:             "",
+			Ours:             "a",
+			Theirs:           "b",
+			Expected:         "<<<<<<< current\na\n=======\nb\n>>>>>>> generated",
+			ExpectedConflict: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			result, conflict := Merge(testCase.Base, testCase.Ours, testCase.Theirs, labels)
+
+			if conflict != testCase.ExpectedConflict {
+				t.Errorf("Merge conflict wanted %v, got %v", testCase.ExpectedConflict, conflict)
+			}
+
+			if result != testCase.Expected {
+				t.Errorf("Merge wanted:\n%s\nBut got:\n%s", testCase.Expected, result)
+			}
+		})
+	}
+}
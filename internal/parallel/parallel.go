@@ -0,0 +1,53 @@
+// Package parallel runs independent jobs over a pool of workers
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Workers - Number of goroutines used by Run, one per available CPU
+func Workers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// Run calls fn for every index from 0 to n-1 over a pool of workers. All the
+// jobs run even when some fail. The error of the lowest failing index is
+// returned, so the result does not depend on the scheduling.
+func Run(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	jobs := make(chan int)
+	workers := Workers()
+
+	if workers > n {
+		workers = n
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
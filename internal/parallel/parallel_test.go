@@ -0,0 +1,53 @@
+package parallel
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var calls int64
+	results := make([]int, 100)
+
+	err := Run(len(results), func(i int) error {
+		atomic.AddInt64(&calls, 1)
+		results[i] = i * 2
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != int64(len(results)) {
+		t.Fatalf("expected %d calls, got %d", len(results), calls)
+	}
+
+	for i, result := range results {
+		if result != i*2 {
+			t.Fatalf("expected result %d to be %d, got %d", i, i*2, result)
+		}
+	}
+}
+
+func TestRunError(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		err := Run(50, func(i int) error {
+			if i%7 == 3 {
+				return fmt.Errorf("job %d failed", i)
+			}
+			return nil
+		})
+
+		if err == nil || err.Error() != "job 3 failed" {
+			t.Fatalf("expected the error of the first failing job, got %v", err)
+		}
+	}
+}
+
+func TestRunEmpty(t *testing.T) {
+	if err := Run(0, func(i int) error { return fmt.Errorf("unexpected call") }); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
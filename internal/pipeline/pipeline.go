@@ -0,0 +1,102 @@
+// Package pipeline selects and runs the post build steps of a strategy
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Select - Returns the steps to run. The steps named in options.Steps run in
+// that order; when it is empty, the default steps run, followed by the extra
+// steps of the options. The skipped steps are removed in both cases.
+func Select(available []*entities.Step, defaults []string, options *entities.Options) ([]*entities.Step, error) {
+	if options == nil {
+		options = &entities.Options{}
+	}
+
+	byName := make(map[string]*entities.Step)
+	names := make([]string, 0, len(available)+len(options.ExtraSteps))
+
+	for _, step := range append(append([]*entities.Step{}, available...), options.ExtraSteps...) {
+		if _, ok := byName[step.Name]; ok {
+			return nil, fmt.Errorf("post build step %q is declared twice", step.Name)
+		}
+
+		byName[step.Name] = step
+		names = append(names, step.Name)
+	}
+
+	selected := options.Steps
+
+	if len(selected) == 0 {
+		selected = append([]string{}, defaults...)
+
+		for _, step := range options.ExtraSteps {
+			selected = append(selected, step.Name)
+		}
+	}
+
+	for _, name := range append(append([]string{}, selected...), options.SkipSteps...) {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("unknown post build step %q, the steps are: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	result := make([]*entities.Step, 0, len(selected))
+
+	for _, name := range selected {
+		if !contains(options.SkipSteps, name) {
+			result = append(result, byName[name])
+		}
+	}
+
+	return result, nil
+}
+
+// Run - Runs the steps in order, stopping at the first that fails. The output
+// of the steps is discarded.
+func Run(steps []*entities.Step, projectPath string) error {
+	for _, step := range steps {
+		err := step.Run(projectPath, io.Discard)
+
+		if err != nil {
+			return fmt.Errorf("on step %s: %v", step.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Command - Runs a command in dir, with env added to the environment. Its
+// standard output and error are written to output, and the standard error is
+// also returned with the failure.
+func Command(dir string, env []string, output io.Writer, name string, args ...string) error {
+	var errb bytes.Buffer
+	command := exec.Command(name, args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), env...)
+	command.Stdout = output
+	command.Stderr = io.MultiWriter(output, &errb)
+	err := command.Run()
+
+	if err != nil {
+		return fmt.Errorf("on running command %s: %v: %s", command.String(), err, errb.String())
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type selectTestCase struct {
+	Description   string
+	Options       *entities.Options
+	ExpectedSteps string
+	ExpectedError string
+}
+
+func newStep(name string, calls *[]string) *entities.Step {
+	return &entities.Step{Name: name, Run: func(projectPath string, output io.Writer) error {
+		*calls = append(*calls, name)
+		return nil
+	}}
+}
+
+func TestSelect(t *testing.T) {
+	var calls []string
+	available := []*entities.Step{newStep("format", &calls), newStep("tidy", &calls), newStep("build", &calls), newStep("test", &calls)}
+	defaults := []string{"format", "build", "test"}
+
+	testCases := []*selectTestCase{
+		{
+			Description:   "defaults",
+			ExpectedSteps: "format,build,test",
+		},
+		{
+			Description:   "skipped steps",
+			Options:       &entities.Options{SkipSteps: []string{"test"}},
+			ExpectedSteps: "format,build",
+		},
+		{
+			Description:   "enabled steps",
+			Options:       &entities.Options{Steps: []string{"tidy", "format"}},
+			ExpectedSteps: "tidy,format",
+		},
+		{
+			Description:   "extra steps",
+			Options:       &entities.Options{ExtraSteps: []*entities.Step{newStep("lint", &calls)}, SkipSteps: []string{"build"}},
+			ExpectedSteps: "format,test,lint",
+		},
+		{
+			Description:   "unknown step",
+			Options:       &entities.Options{SkipSteps: []string{"tset"}},
+			ExpectedError: `unknown post build step "tset", the steps are: format, tidy, build, test`,
+		},
+		{
+			Description:   "extra step with the name of a strategy step",
+			Options:       &entities.Options{ExtraSteps: []*entities.Step{newStep("build", &calls)}},
+			ExpectedError: `post build step "build" is declared twice`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			steps, err := Select(available, defaults, testCase.Options)
+
+			if testCase.ExpectedError != "" {
+				if err == nil || err.Error() != testCase.ExpectedError {
+					t.Fatalf("expected error %q, got %v", testCase.ExpectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := make([]string, 0, len(steps))
+
+			for _, step := range steps {
+				names = append(names, step.Name)
+			}
+
+			if strings.Join(names, ",") != testCase.ExpectedSteps {
+				t.Fatalf("expected steps %s, got %s", testCase.ExpectedSteps, strings.Join(names, ","))
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	var calls []string
+	failing := &entities.Step{Name: "build", Run: func(projectPath string, output io.Writer) error {
+		return fmt.Errorf("compilation failed in %s", projectPath)
+	}}
+
+	err := Run([]*entities.Step{newStep("format", &calls), failing, newStep("test", &calls)}, "out")
+
+	if err == nil || err.Error() != "on step build: compilation failed in out" {
+		t.Fatalf("expected the error of the build step, got %v", err)
+	}
+
+	if strings.Join(calls, ",") != "format" {
+		t.Fatalf("the steps after the failing one should not run, got %v", calls)
+	}
+}
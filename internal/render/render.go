@@ -0,0 +1,137 @@
+// Package render renders the file maps of the strategies, whatever the
+// language of the stack
+package render
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danilo-medeiros/fancybuild/engine/internal/parallel"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Format - Tidies the rendered text of the file at finalPath, failing when the
+// text is not valid for the kind of the file
+type Format func(finalPath string, text string) (string, error)
+
+// Files - Renders the files with the cache over a pool of workers. Files
+// without a template already have their content, e.g. the migrations of the
+// last build, and are kept as they are. Every file is given to rendered, when
+// not nil, as soon as it is ready. When several files fail, the error of the
+// first one by key is returned.
+func Files(cache *templates.Cache, fileMap map[string]*entities.File, format Format, rendered func(*entities.File)) error {
+	keys := make([]string, 0, len(fileMap))
+
+	for key := range fileMap {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return parallel.Run(len(keys), func(i int) error {
+		file := fileMap[keys[i]]
+
+		if file.TemplatePath != "" {
+			result, err := cache.Render(file.TemplatePath, keys[i], file.Data)
+
+			if err != nil {
+				return err
+			}
+
+			file.Result, err = format(file.FinalPath, result)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if rendered != nil {
+			rendered(file)
+		}
+
+		return nil
+	})
+}
+
+// ManifestFiles - Adds the files declared by the manifests of the template
+// override directories. App scoped files are rendered with the definitions,
+// entity scoped ones with the definitions and the entity, once for every
+// entity that has a controller. The manifests only add new files, the
+// generated ones are replaced by overriding their templates, so a key or a
+// final path already in the file map is an error.
+func ManifestFiles(overlay *templates.Overlay, definitions *entities.Definitions, fileMap map[string]*entities.File) error {
+	manifest, err := overlay.Manifest()
+
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[string]string)
+
+	for key, file := range fileMap {
+		paths[file.FinalPath] = key
+	}
+
+	add := func(key string, file *entities.File) error {
+		if _, ok := fileMap[key]; ok {
+			return fmt.Errorf("manifest file %s: the key is already used by a generated file, override its template instead", key)
+		}
+
+		if other, ok := paths[file.FinalPath]; ok {
+			return fmt.Errorf("manifest file %s: %s is already generated as %s, override its template instead", key, file.FinalPath, other)
+		}
+
+		fileMap[key] = file
+		paths[file.FinalPath] = key
+		return nil
+	}
+
+	for _, file := range manifest.Files {
+		if file.Scope == templates.ScopeApp {
+			err := add(file.Key, &entities.File{
+				FinalPath:    file.FinalPath,
+				TemplatePath: file.TemplatePath,
+				Data:         definitions,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		for _, entity := range definitions.App.Entities {
+			if !entity.HasController() {
+				continue
+			}
+
+			var data struct {
+				*entities.Definitions
+				*entities.Entity
+			}
+
+			data.Definitions = definitions
+			data.Entity = entity
+
+			finalPath, err := templates.RenderText(file.Key, file.FinalPath, data, templates.DefaultFuncMap())
+
+			if err != nil {
+				return err
+			}
+
+			err = add(fmt.Sprintf("%s_%s", entity.Name, file.Key), &entities.File{
+				FinalPath:    finalPath,
+				TemplatePath: file.TemplatePath,
+				Data:         data,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+
+	if err == nil {
+		err = os.WriteFile(path, []byte(content), 0644)
+	}
+
+	if err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "name.tmpl"), "{{ .App.Name }}")
+	writeFile(t, filepath.Join(dir, "broken.tmpl"), "{{ .Missing }}")
+
+	definitions := &entities.Definitions{App: &entities.App{Name: "blog"}}
+	cache := templates.NewCache(templates.NewOverlay(dir), nil)
+	format := func(finalPath string, text string) (string, error) {
+		if finalPath == "invalid.txt" {
+			return "", fmt.Errorf("on formatting %s", finalPath)
+		}
+
+		return fmt.Sprintf("%s: %s", finalPath, text), nil
+	}
+
+	fileMap := map[string]*entities.File{
+		"name":     {FinalPath: "name.txt", TemplatePath: "name.tmpl", Data: definitions},
+		"verbatim": {FinalPath: "verbatim.txt", Result: "{{ kept }}"},
+	}
+
+	var mu sync.Mutex
+	rendered := make(map[string]string)
+
+	err := Files(cache, fileMap, format, func(file *entities.File) {
+		mu.Lock()
+		defer mu.Unlock()
+		rendered[file.FinalPath] = file.Result
+	})
+
+	if err != nil {
+		t.Fatalf("Files returned an error: %s", err)
+	}
+
+	if len(rendered) != 2 || rendered["name.txt"] != "name.txt: blog" || rendered["verbatim.txt"] != "{{ kept }}" {
+		t.Errorf("expected every file to be reported once rendered, got %v", rendered)
+	}
+
+	if fileMap["name"].Result != "name.txt: blog" {
+		t.Errorf("expected the formatted result of the template, got %q", fileMap["name"].Result)
+	}
+
+	if fileMap["verbatim"].Result != "{{ kept }}" {
+		t.Errorf("expected the file without a template to be kept, got %q", fileMap["verbatim"].Result)
+	}
+
+	fileMap["invalid"] = &entities.File{FinalPath: "invalid.txt", TemplatePath: "name.tmpl", Data: definitions}
+	err = Files(cache, fileMap, format, nil)
+
+	if err == nil || err.Error() != "on formatting invalid.txt" {
+		t.Errorf("expected the error of the format, got %v", err)
+	}
+
+	delete(fileMap, "invalid")
+	fileMap["b_broken"] = &entities.File{FinalPath: "b.txt", TemplatePath: "broken.tmpl", Data: definitions}
+	fileMap["a_broken"] = &entities.File{FinalPath: "a.txt", TemplatePath: "broken.tmpl", Data: definitions}
+	err = Files(cache, fileMap, format, nil)
+
+	if err == nil || !strings.Contains(err.Error(), "a_broken") {
+		t.Errorf("expected the error of the first failing key, got %v", err)
+	}
+}
+
+func TestManifestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, templates.ManifestFileName), `{
+		"files": [
+			{"key": "makefile", "finalPath": "Makefile", "templatePath": "extra/makefile.tmpl"},
+			{"key": "handler", "finalPath": "pkg/{{.Entity.Name}}/handler.go", "templatePath": "extra/handler.tmpl", "scope": "entity"}
+		]
+	}`)
+
+	post := &entities.Entity{Name: "post", Persisted: true}
+	tag := &entities.Entity{Name: "tag"}
+	definitions := &entities.Definitions{App: &entities.App{Name: "blog", Entities: []*entities.Entity{post, tag}}}
+	post.Definitions = definitions
+	tag.Definitions = definitions
+	fileMap := make(map[string]*entities.File)
+
+	if err := ManifestFiles(templates.NewOverlay(dir), definitions, fileMap); err != nil {
+		t.Fatalf("ManifestFiles returned an error: %s", err)
+	}
+
+	if len(fileMap) != 2 {
+		t.Fatalf("expected the makefile and the handler of post, got %d files", len(fileMap))
+	}
+
+	if file := fileMap["makefile"]; file.FinalPath != "Makefile" || file.Data != definitions {
+		t.Errorf("expected the makefile rendered with the definitions, got %+v", file)
+	}
+
+	if file := fileMap["post_handler"]; file == nil || file.FinalPath != "pkg/post/handler.go" {
+		t.Errorf("expected the handler of post, got %+v", file)
+	}
+}
+
+func TestManifestFilesCollision(t *testing.T) {
+	post := &entities.Entity{Name: "post", Persisted: true}
+	definitions := &entities.Definitions{App: &entities.App{Name: "blog", Entities: []*entities.Entity{post}}}
+	post.Definitions = definitions
+
+	tests := []struct {
+		Description string
+		File        string
+		Expected    string
+	}{
+		{
+			Description: "app key of a generated file",
+			File:        `{"key": "main", "finalPath": "cmd/main.go", "templatePath": "extra/main.tmpl"}`,
+			Expected:    "manifest file main: the key is already used by a generated file",
+		},
+		{
+			Description: "entity key of a generated file",
+			File:        `{"key": "controller", "finalPath": "pkg/{{.Entity.Name}}/handler.go", "templatePath": "extra/handler.tmpl", "scope": "entity"}`,
+			Expected:    "manifest file post_controller: the key is already used by a generated file",
+		},
+		{
+			Description: "path of a generated file",
+			File:        `{"key": "entry", "finalPath": "main.go", "templatePath": "extra/main.tmpl"}`,
+			Expected:    "manifest file entry: main.go is already generated as main",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, templates.ManifestFileName), `{"files": [`+test.File+`]}`)
+
+			fileMap := map[string]*entities.File{
+				"main":            {FinalPath: "main.go", TemplatePath: "go/fiber/main.tmpl"},
+				"post_controller": {FinalPath: "pkg/post/controller.go", TemplatePath: "go/fiber/controller.tmpl"},
+			}
+
+			err := ManifestFiles(templates.NewOverlay(dir), definitions, fileMap)
+
+			if err == nil || !strings.HasPrefix(err.Error(), test.Expected) {
+				t.Errorf("expected the error %q, got %v", test.Expected, err)
+			}
+
+			if fileMap["main"].TemplatePath != "go/fiber/main.tmpl" || fileMap["post_controller"].TemplatePath != "go/fiber/controller.tmpl" {
+				t.Errorf("expected the generated files to be kept, got %+v", fileMap)
+			}
+		})
+	}
+}
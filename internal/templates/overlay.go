@@ -0,0 +1,154 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+const (
+	// Name of the file, at the root of an override directory, that adds files to the project
+	ManifestFileName = "manifest.json"
+
+	ScopeApp    = "app"    // The file is rendered once for the whole project
+	ScopeEntity = "entity" // The file is rendered once for every entity that has a controller
+)
+
+// Overlay - A template file system where user-supplied directories are searched,
+// in order, before the built-in templates. Files are keyed by the same paths used
+// as TemplatePath by the strategies, e.g. "go/service.tmpl".
+type Overlay struct {
+	dirs []string
+}
+
+// ManifestFile - A file added to the project by an override directory. For entity
+// scoped files the final path is itself a template, rendered with the entity data.
+type ManifestFile struct {
+	Key          string `json:"key"`
+	FinalPath    string `json:"finalPath"`
+	TemplatePath string `json:"templatePath"`
+	Scope        string `json:"scope"`
+}
+
+type Manifest struct {
+	Files []*ManifestFile `json:"files"`
+}
+
+func (o *Overlay) Open(name string) (fs.File, error) {
+	for _, dir := range o.dirs {
+		f, err := os.DirFS(dir).Open(name)
+
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return files.Open(name)
+}
+
+// Overrides - Checks if an override directory has the file, which is then
+// opened instead of the built-in template
+func (o *Overlay) Overrides(name string) bool {
+	for _, dir := range o.dirs {
+		if _, err := fs.Stat(os.DirFS(dir), name); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lookup - Path of the template of a file of a stack under root, e.g. "go".
+// It is looked up in the folders of the stack, e.g. its database, then in the
+// ones of its framework, before the shared templates of root. An override of
+// a shared template takes precedence over the built-in ones of the framework,
+// so it applies to every framework.
+func (o *Overlay) Lookup(root string, name string, stackFolders []string, frameworkFolders []string) string {
+	shared := fmt.Sprintf("%s/%s.tmpl", root, name)
+
+	if path, ok := o.find(root, name, stackFolders); ok {
+		return path
+	}
+
+	if o.Overrides(shared) {
+		return shared
+	}
+
+	if path, ok := o.find(root, name, frameworkFolders); ok {
+		return path
+	}
+
+	return shared
+}
+
+// Finds the template of a file in the first of the folders that has it
+func (o *Overlay) find(root string, name string, folders []string) (string, bool) {
+	for _, folder := range folders {
+		path := fmt.Sprintf("%s/%s/%s.tmpl", root, folder, name)
+
+		if _, err := fs.Stat(o, path); err == nil {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// Manifest - Merges the manifests of all the override directories. When two
+// directories declare the same key, the one searched first wins.
+func (o *Overlay) Manifest() (*Manifest, error) {
+	result := &Manifest{Files: make([]*ManifestFile, 0)}
+	seen := make(map[string]bool)
+
+	for _, dir := range o.dirs {
+		content, err := fs.ReadFile(os.DirFS(dir), ManifestFileName)
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading manifest of %s: %v", dir, err)
+		}
+
+		var manifest Manifest
+		err = json.Unmarshal(content, &manifest)
+
+		if err != nil {
+			return nil, fmt.Errorf("parsing manifest of %s: %v", dir, err)
+		}
+
+		for index, file := range manifest.Files {
+			if file.Key == "" || file.FinalPath == "" || file.TemplatePath == "" {
+				return nil, fmt.Errorf("manifest of %s: files[%d] requires key, finalPath and templatePath", dir, index)
+			}
+
+			if file.Scope == "" {
+				file.Scope = ScopeApp
+			}
+
+			if file.Scope != ScopeApp && file.Scope != ScopeEntity {
+				return nil, fmt.Errorf("manifest of %s: files[%d] has unknown scope %q", dir, index, file.Scope)
+			}
+
+			if seen[file.Key] {
+				continue
+			}
+
+			seen[file.Key] = true
+			result.Files = append(result.Files, file)
+		}
+	}
+
+	return result, nil
+}
+
+func NewOverlay(dirs ...string) *Overlay {
+	return &Overlay{dirs}
+}
@@ -0,0 +1,130 @@
+package templates
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+
+	if err == nil {
+		err = os.WriteFile(path, []byte(content), 0644)
+	}
+
+	if err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+}
+
+func TestOverlayOpen(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeFile(t, filepath.Join(first, "go/readme.tmpl"), "first")
+	writeFile(t, filepath.Join(second, "go/readme.tmpl"), "second")
+	writeFile(t, filepath.Join(second, "go/gitignore.tmpl"), "second")
+
+	overlay := NewOverlay(first, second)
+	expected := map[string]string{
+		"go/readme.tmpl":    "first",
+		"go/gitignore.tmpl": "second",
+	}
+
+	for path, value := range expected {
+		content, err := fs.ReadFile(overlay, path)
+
+		if err != nil {
+			t.Fatalf("reading %s: %s", path, err)
+		}
+
+		if string(content) != value {
+			t.Errorf("reading %s wanted %q, got %q", path, value, content)
+		}
+	}
+
+	if !overlay.Overrides("go/gitignore.tmpl") || overlay.Overrides("go/service.tmpl") {
+		t.Errorf("only the files of the override directories should be overrides")
+	}
+
+	builtin, err := fs.ReadFile(files, "go/service.tmpl")
+
+	if err != nil {
+		t.Fatalf("reading built-in template: %s", err)
+	}
+
+	content, err := fs.ReadFile(overlay, "go/service.tmpl")
+
+	if err != nil || string(content) != string(builtin) {
+		t.Errorf("reading go/service.tmpl should fall back to the built-in template")
+	}
+}
+
+func TestOverlayManifest(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	writeFile(t, filepath.Join(first, ManifestFileName), `{
+		"files": [
+			{"key": "makefile", "finalPath": "Makefile", "templatePath": "extra/makefile.tmpl"}
+		]
+	}`)
+	writeFile(t, filepath.Join(second, ManifestFileName), `{
+		"files": [
+			{"key": "makefile", "finalPath": "build/Makefile", "templatePath": "extra/makefile.tmpl"},
+			{"key": "handler", "finalPath": "pkg/{{.Entity.Name}}/handler.go", "templatePath": "extra/handler.tmpl", "scope": "entity"}
+		]
+	}`)
+
+	manifest, err := NewOverlay(first, second).Manifest()
+
+	if err != nil {
+		t.Fatalf("reading manifest: %s", err)
+	}
+
+	if len(manifest.Files) != 2 {
+		t.Fatalf("manifest wanted 2 files, got %d", len(manifest.Files))
+	}
+
+	if manifest.Files[0].FinalPath != "Makefile" || manifest.Files[0].Scope != ScopeApp {
+		t.Errorf("manifest file makefile should come from the first directory with app scope, got %+v", manifest.Files[0])
+	}
+
+	if manifest.Files[1].Scope != ScopeEntity {
+		t.Errorf("manifest file handler wanted entity scope, got %s", manifest.Files[1].Scope)
+	}
+
+	writeFile(t, filepath.Join(first, ManifestFileName), `{"files": [{"key": "x", "finalPath": "x", "templatePath": "x", "scope": "module"}]}`)
+	_, err = NewOverlay(first).Manifest()
+
+	if err == nil {
+		t.Errorf("manifest with an unknown scope should return an error")
+	}
+}
+
+func TestOverlayLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go/controller.tmpl"), "shared")
+	writeFile(t, filepath.Join(dir, "go/memory/database.tmpl"), "database")
+
+	overlay := NewOverlay(dir)
+	stack := []string{"memory"}
+	framework := []string{"nethttp"}
+
+	tests := []struct {
+		Description string
+		Name        string
+		Expected    string
+	}{
+		{Description: "override of the stack", Name: "database", Expected: "go/memory/database.tmpl"},
+		{Description: "shared override before the framework", Name: "controller", Expected: "go/controller.tmpl"},
+		{Description: "built-in template of the framework", Name: "router", Expected: "go/nethttp/router.tmpl"},
+		{Description: "shared built-in template", Name: "service", Expected: "go/service.tmpl"},
+	}
+
+	for _, test := range tests {
+		if path := overlay.Lookup("go", test.Name, stack, framework); path != test.Expected {
+			t.Errorf("%s: expected %s, got %s", test.Description, test.Expected, path)
+		}
+	}
+}
@@ -1,47 +1,144 @@
 package templates
 
 import (
+	"embed"
 	"fmt"
-	"os"
+	"io/fs"
 	"regexp"
 	"strings"
+	"sync"
 	"text/template"
 )
 
+// Template files shipped with the engine, so rendering does not depend on the
+// current working directory
+//
+//go:embed go typescript
+var files embed.FS
+
 type Template struct {
 	Path    string
 	Name    string
 	Data    interface{}
 	FuncMap template.FuncMap
+	FS      fs.FS // Where the template is read from, the built-in templates when nil
 }
 
 func Render(t *Template) (string, error) {
-	sb := strings.Builder{}
-	path := fmt.Sprintf("internal/templates/%s", t.Path)
-	content, err := os.ReadFile(path)
+	fsys := t.FS
+
+	if fsys == nil {
+		fsys = files
+	}
+
+	content, err := fs.ReadFile(fsys, t.Path)
 
 	if err != nil {
 		return "", fmt.Errorf("reading template file %s: %v", t.Name, err)
 	}
 
+	return RenderText(t.Name, string(content), t.Data, t.FuncMap)
+}
+
+// RenderText - Renders a template that is already in memory
+func RenderText(name string, text string, data interface{}, funcMap template.FuncMap) (string, error) {
+	sb := strings.Builder{}
 	parsedTemplate, err := template.
-		New(t.Name).
-		Funcs(t.FuncMap).
-		Parse(string(content))
+		New(name).
+		Funcs(funcMap).
+		Parse(text)
+
+	if err != nil {
+		return "", fmt.Errorf("parsing template file %s: %v", name, err)
+	}
+
+	err = parsedTemplate.Execute(&sb, data)
+
+	if err != nil {
+		return "", fmt.Errorf("rendering template %s: %v", name, err)
+	}
+
+	return sb.String(), nil
+}
+
+// Cache - Parses every template file once and renders it many times. It is
+// safe for concurrent use.
+type Cache struct {
+	fs      fs.FS
+	funcMap template.FuncMap
+	mu      sync.Mutex
+	parsed  map[string]*cachedTemplate
+}
+
+type cachedTemplate struct {
+	once     sync.Once
+	template *template.Template
+	err      error
+}
+
+// NewCache - Creates a cache of the templates of fsys, the built-in templates when
+// nil. The functions of funcMap are available to all the templates.
+func NewCache(fsys fs.FS, funcMap template.FuncMap) *Cache {
+	if fsys == nil {
+		fsys = files
+	}
+
+	return &Cache{
+		fs:      fsys,
+		funcMap: funcMap,
+		parsed:  make(map[string]*cachedTemplate),
+	}
+}
+
+// Render - Renders the template file at path. The name identifies the rendered
+// file in the errors.
+func (c *Cache) Render(path string, name string, data interface{}) (string, error) {
+	t, err := c.lookup(path)
 
 	if err != nil {
-		return "", fmt.Errorf("parsing template file %s: %v", t.Name, err)
+		return "", err
 	}
 
-	err = parsedTemplate.Execute(&sb, t.Data)
+	sb := strings.Builder{}
+	err = t.Execute(&sb, data)
 
 	if err != nil {
-		return "", fmt.Errorf("rendering template %s: %v", t.Name, err)
+		return "", fmt.Errorf("rendering template %s: %v", name, err)
 	}
 
 	return sb.String(), nil
 }
 
+func (c *Cache) lookup(path string) (*template.Template, error) {
+	c.mu.Lock()
+	entry, ok := c.parsed[path]
+
+	if !ok {
+		entry = &cachedTemplate{}
+		c.parsed[path] = entry
+	}
+
+	c.mu.Unlock()
+
+	// Parsed outside of the lock, so different templates are parsed concurrently
+	entry.once.Do(func() {
+		content, err := fs.ReadFile(c.fs, path)
+
+		if err != nil {
+			entry.err = fmt.Errorf("reading template file %s: %v", path, err)
+			return
+		}
+
+		entry.template, err = template.New(path).Funcs(c.funcMap).Parse(string(content))
+
+		if err != nil {
+			entry.err = fmt.Errorf("parsing template file %s: %v", path, err)
+		}
+	})
+
+	return entry.template, entry.err
+}
+
 func DefaultFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"capitalize": Capitalize,
@@ -172,23 +269,23 @@ func Empty(text string) bool {
 	return len(text) == 0
 }
 
+// Patterns used by SimpleFormat, compiled once as it runs for every rendered file
+var (
+	lineBreaksPattern = regexp.MustCompile("\n+")
+	//lint:ignore S1007 we want to define a regular expression with a conditional
+	openingBlockPattern = regexp.MustCompile("{$|\\($")
+	closingBlockPattern = regexp.MustCompile("}$|^\t+\\)$|^\\)$")
+	commentPattern      = regexp.MustCompile("^\t*//.*")
+	importSpecPattern   = regexp.MustCompile(`^\t*([\w.]+\s+)?"[^"]*"$`)
+	regionBeginPattern  = regexp.MustCompile(`^\t*// fancybuild:begin `)
+)
+
 // SimpleFormat - Runs a simple formatting in a string
 func SimpleFormat(text string) string {
-	result := text
-
-	{
-		pattern := regexp.MustCompile("\n+")
-		result = pattern.ReplaceAllString(result, "\n")
-	}
-
+	result := lineBreaksPattern.ReplaceAllString(text, "\n")
 	lines := strings.Split(result, "\n")
 	previousLineHasBreak := false
 
-	//lint:ignore S1007 we want to define a regular expression with a conditional
-	openingBlockPattern := regexp.MustCompile("{$|\\($")
-	closingBlockPattern := regexp.MustCompile("}$|^\t+\\)$|^\\)$")
-	commentPattern := regexp.MustCompile("^\t*//.*")
-
 	for i, line := range lines {
 		isPreviousLineComment := false
 		isPreviousLineOpeningBlock := false
@@ -199,6 +296,13 @@ func SimpleFormat(text string) string {
 			isPreviousLineComment = commentPattern.MatchString(lines[i-1])
 		}
 
+		// A protected region in an import block is a group of its own, so the
+		// imports written in it are not sorted among the generated ones
+		if i-1 >= 0 && regionBeginPattern.MatchString(line) && importSpecPattern.MatchString(lines[i-1]) {
+			lines[i] = fmt.Sprintf("\n%s", line)
+			continue
+		}
+
 		if isCurrentLineOpeningBlock && !previousLineHasBreak && !isPreviousLineOpeningBlock && !isPreviousLineComment {
 			lines[i] = fmt.Sprintf("\n%s", line)
 			continue
@@ -1,6 +1,10 @@
 package templates
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
 
 func TestPluralize(t *testing.T) {
 	nounMap := map[string]string{
@@ -55,6 +59,8 @@ const testSimpleFormatInput = `package test
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	// fancybuild:begin custom-imports
+	// fancybuild:end custom-imports
 )
 func test(a string, b string) {
 	fmt.Printf(
@@ -87,6 +93,9 @@ const testSimpleFormatExpected = `package test
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+
+	// fancybuild:begin custom-imports
+	// fancybuild:end custom-imports
 )
 
 func test(a string, b string) {
@@ -130,3 +139,69 @@ func TestSimpleFormat(t *testing.T) {
 		t.Errorf("SimpleFormat wanted:\n%s\nBut got:\n%s\n", expected, actual)
 	}
 }
+
+func TestRender(t *testing.T) {
+	result, err := Render(&Template{
+		Path: "go/readme.tmpl",
+		Name: "readme",
+		Data: map[string]interface{}{
+			"Id": "1",
+			"App": map[string]string{
+				"Name":        "example",
+				"Description": "An example",
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("Render returned an error: %s", err)
+	}
+
+	expected := "# example\n\nAn example\n\nGenerated by fancybuild.\nID 1\n"
+
+	if result != expected {
+		t.Errorf("Render wanted:\n%s\nBut got:\n%s\n", expected, result)
+	}
+
+	_, err = Render(&Template{Path: "go/i_dont_exist.tmpl", Name: "missing"})
+
+	if err == nil {
+		t.Errorf("Render of a missing template should return an error")
+	}
+}
+
+func TestCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"hello.tmpl":  {Data: []byte("Hello {{ capitalize . }}")},
+		"broken.tmpl": {Data: []byte("{{ if }}")},
+	}
+	cache := NewCache(fsys, DefaultFuncMap())
+
+	for _, name := range []string{"world", "fancybuild"} {
+		result, err := cache.Render("hello.tmpl", "hello", name)
+
+		if err != nil {
+			t.Fatalf("Render returned an error: %s", err)
+		}
+
+		if expected := "Hello " + Capitalize(name); result != expected {
+			t.Errorf("Render wanted %q, got %q", expected, result)
+		}
+	}
+
+	// Changes to the file system are not seen once the template is parsed
+	fsys["hello.tmpl"].Data = []byte("Bye")
+	result, _ := cache.Render("hello.tmpl", "hello", "world")
+
+	if result != "Hello World" {
+		t.Errorf("Render should use the parsed template, got %q", result)
+	}
+
+	if _, err := cache.Render("broken.tmpl", "broken", nil); err == nil || !strings.Contains(err.Error(), "parsing template file broken.tmpl") {
+		t.Errorf("Render of an invalid template should return a parsing error, got %v", err)
+	}
+
+	if _, err := cache.Render("missing.tmpl", "missing", nil); err == nil {
+		t.Errorf("Render of a missing template should return an error")
+	}
+}
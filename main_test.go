@@ -9,11 +9,11 @@ import (
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript"
 )
 
-const OutputFolder = "/home/danilo/development/personal/generated-projects"
-
-func subTest(file string) func(t *testing.T) {
+func subTest(file string, language string, framework string, database string) func(t *testing.T) {
 	return func(t *testing.T) {
 		data, err := os.ReadFile(fmt.Sprintf("./_examples/%s", file))
 
@@ -24,14 +24,17 @@ func subTest(file string) func(t *testing.T) {
 		var definition entities.Definitions
 		r := reader.NewReader()
 
-		err = r.Read(data, &definition)
+		positions, err := r.Read(data, &definition)
 
 		if err != nil {
 			panic(fmt.Sprintf("error on parsing definition: %s", err))
 		}
 
 		definition.Id = fmt.Sprintf("%v", file)
-		validationErrs := r.Validate(&definition)
+		definition.App.Stack.Language = language
+		definition.App.Stack.Framework = framework
+		definition.App.Stack.Database = database
+		validationErrs := r.Validate(&definition, positions)
 
 		if validationErrs != nil {
 			for _, fieldErr := range validationErrs.Errors {
@@ -39,14 +42,27 @@ func subTest(file string) func(t *testing.T) {
 			}
 		}
 
-		stgy := strategy.NewStrategy(&definition)
+		options := &entities.Options{Offline: true}
+
+		// The tests of the generated projects need the database and Redis, except with SQLite and memory
+		if database != strategy.SQLite && database != strategy.Memory {
+			options.SkipSteps = []string{common.StepTest}
+		}
+
+		// The dependencies of the TypeScript projects need network access, and
+		// the type check needs the dependencies
+		if language == strategy.TypeScript {
+			options.SkipSteps = []string{typescript.StepInstall, typescript.StepTypecheck, typescript.StepTest}
+		}
+
+		stgy, err := strategy.NewStrategy(&definition, options)
 
-		if stgy == nil {
-			t.Fatalf("error: strategy not found for %v", definition)
+		if err != nil {
+			t.Fatalf("error on creating strategy: %s", err)
 		}
 
-		b := builder.NewBuilder(OutputFolder)
-		err = b.Build(&definition, stgy)
+		b := builder.NewBuilder(nil)
+		_, err = b.Build(&definition, stgy, builder.NewDirOutput(t.TempDir()))
 
 		if err != nil {
 			t.Fatalf("error on building project: %s", err)
@@ -58,10 +74,23 @@ func TestExamples(t *testing.T) {
 	files := []string{
 		"blog.json",
 		"todoapp.json",
+		"todoapp.yaml",
 		"ecommerce.json",
 	}
 
 	for _, file := range files {
-		t.Run(file, subTest(file))
+		for _, database := range []string{strategy.MongoDB, strategy.Postgres, strategy.SQLite, strategy.Memory} {
+			t.Run(fmt.Sprintf("%s/%s", file, database), subTest(file, strategy.GoLang, strategy.Fiber, database))
+		}
+
+		// The frameworks are tested with the database that needs no services
+		for _, framework := range []string{strategy.NetHTTP, strategy.Chi} {
+			t.Run(fmt.Sprintf("%s/%s", file, framework), subTest(file, strategy.GoLang, framework, strategy.Memory))
+		}
+
+		// The TypeScript stacks only have MongoDB
+		for _, framework := range []string{strategy.Express, strategy.Fastify} {
+			t.Run(fmt.Sprintf("%s/%s/%s", file, strategy.TypeScript, framework), subTest(file, strategy.TypeScript, framework, strategy.MongoDB))
+		}
 	}
 }
@@ -1,65 +1,482 @@
 package builder
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/danilo-medeiros/fancybuild/engine/internal/merge"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/parallel"
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
 )
 
+// ConflictPolicy - What to do with the files the user changed since the last build
+type ConflictPolicy string
+
+const (
+	ConflictFail      ConflictPolicy = "fail"      // Refuse to build, without writing any file
+	ConflictSideFile  ConflictPolicy = "side-file" // Keep the user file and write the new one next to it
+	ConflictOverwrite ConflictPolicy = "overwrite" // Overwrite the user changes
+	ConflictMerge     ConflictPolicy = "merge"     // Three-way merge the user changes with the new version
+
+	// Suffix of the files written next to the user files by ConflictSideFile
+	SideFileSuffix = ".fancybuild.new"
+)
+
+type Options struct {
+	OnConflict ConflictPolicy // ConflictFail when empty
+	OnEvent    func(Event)    // Receives the progress of the build, one event at a time. Optional.
+}
+
+// BuildReport - Summary of a build
+type BuildReport struct {
+	Conflicts []string      // Files changed by the user since the last build that were not overwritten, or merged with conflicts
+	Merged    []string      // Files changed by the user since the last build, merged without conflicts
+	Stale     []string      // Files of the last build that are not generated anymore and can be deleted
+	Files     []*FileResult // Every file of the project, sorted by path
+	Steps     []*StepResult // The post build steps that ran, in order
+}
+
+// FileResult - A file of the project and how it was written
+type FileResult struct {
+	Path     string // Path in the project
+	Output   string // Name written to the output, different from the path for side files
+	Template string
+	Size     int
+	Conflict bool   // Changed by the user and not overwritten, or merged with conflicts
+	Merged   bool   // Changed by the user and merged without conflicts
+	Hash     string // Hash recorded by the manifest, compared by the next build
+}
+
+// StepResult - Outcome of a post build step
+type StepResult struct {
+	Name     string
+	Duration time.Duration
+	Output   string // What the step printed, e.g. the output of the commands it ran
+	Err      error  // Nil when the step succeeded
+}
+
+// ConflictError - Returned when the build is refused because the user changed generated files
+type ConflictError struct {
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("files changed since the last build: %s", strings.Join(e.Files, ", "))
+}
+
 type Builder interface {
-	Build(*entities.Definitions, entities.Strategy) error
+	// Renders the project and writes it to the output, closing it at the end.
+	// When the output is a ReadableOutput, the protected regions of the files
+	// of a previous build are kept and the files changed by the user are
+	// handled according to the conflict policy. A copy of every generated file
+	// is kept in the project, as the base of the next merge. The strategy post actions only
+	// run when the output is a DiskOutput. When a post build step fails, the
+	// report is returned with the error.
+	Build(*entities.Definitions, entities.Strategy, Output) (*BuildReport, error)
 }
 
 type builder struct {
-	OutputFolder string
+	options *Options
+	mu      sync.Mutex // Serializes the events
 }
 
-func (b *builder) Build(definitions *entities.Definitions, strategy entities.Strategy) error {
+// A file of the project ready to be written
+type plannedFile struct {
+	*entities.File
+	Name      string // Path in the output
+	Generated string // Rendered content, with the protected regions of the existing file
+	Content   string // Content to be written
+	Conflict  bool
+	Merged    bool
+}
+
+func (b *builder) Build(definitions *entities.Definitions, strategy entities.Strategy, output Output) (report *BuildReport, err error) {
+	defer func() {
+		closeErr := output.Close()
+
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("on closing output: %v", closeErr)
+		}
+	}()
+
+	projectPath := path.Join(definitions.Id, definitions.App.Name)
+
+	if incremental, ok := strategy.(entities.IncrementalStrategy); ok {
+		incremental.SetPrevious(func(name string) ([]byte, error) {
+			return readBase(output, projectPath, name)
+		})
+	}
+
+	progress, reportsProgress := strategy.(entities.ProgressStrategy)
+
+	if reportsProgress {
+		progress.SetOnRendered(func(file *entities.File) {
+			b.emit(FileRendered{Path: file.FinalPath, Template: file.TemplatePath})
+		})
+	}
+
 	fileMap, err := strategy.BuildFileMap()
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	previous, err := readManifest(output, projectPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report = &BuildReport{
+		Conflicts: make([]string, 0),
+		Merged:    make([]string, 0),
+		Stale:     make([]string, 0),
+		Files:     make([]*FileResult, 0, len(fileMap)),
+		Steps:     make([]*StepResult, 0),
+	}
+
+	files, err := b.plan(fileMap, projectPath, output, previous)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		// Strategies that do not report their progress rendered all the files by now
+		if !reportsProgress {
+			b.emit(FileRendered{Path: file.FinalPath, Template: file.TemplatePath})
+		}
+
+		if file.Conflict {
+			report.Conflicts = append(report.Conflicts, file.FinalPath)
+		}
+
+		if file.Merged {
+			report.Merged = append(report.Merged, file.FinalPath)
+		}
+	}
+
+	if len(report.Conflicts) > 0 && b.options.OnConflict == ConflictFail {
+		return report, &ConflictError{Files: report.Conflicts}
+	}
+
+	manifest := newManifest()
+	writes := make([]*fileWrite, 0, len(files))
+
+	for _, file := range files {
+		name := file.Name
+
+		if file.Conflict && b.options.OnConflict == ConflictSideFile {
+			name += SideFileSuffix
+			manifest.Files[file.FinalPath] = previous.Files[file.FinalPath]
+		}
+
+		result := &FileResult{
+			Path:     file.FinalPath,
+			Output:   name,
+			Template: file.TemplatePath,
+			Size:     len(file.Content),
+			Conflict: file.Conflict,
+			Merged:   file.Merged,
+		}
+		report.Files = append(report.Files, result)
+
+		writes = append(writes, &fileWrite{name, []byte(file.Content), func() {
+			b.emit(FileWritten{result})
+		}})
+	}
+
+	err = writeFiles(output, writes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil {
+		paths := make(map[string]bool)
+
+		for _, file := range files {
+			paths[file.FinalPath] = true
+		}
+
+		report.Stale = previous.Stale(paths)
+	}
+
+	var stepsErr error
+
+	if diskOutput, ok := output.(DiskOutput); ok {
+		if aware, ok := strategy.(entities.ConflictAwareStrategy); ok {
+			aware.SetConflicts(report.Conflicts)
+		}
+
+		report.Steps, stepsErr = b.runSteps(strategy, filepath.Join(diskOutput.Dir(), filepath.FromSlash(projectPath)))
+	}
+
+	// The files are written, so the manifest is written even when a step
+	// failed, otherwise the next build would take them for changes of the user
+	writes = make([]*fileWrite, 0, len(files))
+
+	for _, file := range files {
+		if manifest.Files[file.FinalPath] != nil {
+			continue
+		}
+
+		// The base of the next merge is what was generated, not what was merged
+		base := file.Generated
+
+		if !file.Conflict && !file.Merged {
+			// The post actions may have changed the files, e.g. formatting them
+			base, err = readBack(output, file.Name, file.Content)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		manifest.Files[file.FinalPath] = &ManifestFile{
+			Template: file.TemplatePath,
+			Hash:     hashContent(base),
+		}
+
+		writes = append(writes, &fileWrite{path.Join(projectPath, basePath, file.FinalPath), []byte(base), nil})
+	}
+
+	err = writeFiles(output, writes)
+
+	if err != nil {
+		return nil, err
 	}
 
-	projectPath := fmt.Sprintf("%s/%s/%s", b.OutputFolder, definitions.Id, definitions.App.Name)
+	err = writeManifest(output, projectPath, manifest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range report.Files {
+		if file := manifest.Files[result.Path]; file != nil {
+			result.Hash = file.Hash
+		}
+	}
+
+	if stepsErr != nil {
+		return report, fmt.Errorf("on build post actions: %s", stepsErr)
+	}
+
+	return report, nil
+}
+
+// Computes the final content of every file and finds the ones changed by the user
+func (b *builder) plan(fileMap map[string]*entities.File, projectPath string, output Output, previous *Manifest) ([]*plannedFile, error) {
+	result := make([]*plannedFile, 0, len(fileMap))
 
 	for _, file := range fileMap {
-		pathSlices := strings.Split(file.FinalPath, "/")
-		pathSlices = pathSlices[0 : len(pathSlices)-1]
-		filePath := strings.Join(pathSlices, "/")
+		planned := &plannedFile{
+			File:      file,
+			Name:      path.Join(projectPath, file.FinalPath),
+			Generated: file.Result,
+			Content:   file.Result,
+		}
 
-		err = os.MkdirAll(fmt.Sprintf("%s/%s", projectPath, filePath), 0744)
+		existing, exists, err := readExisting(output, planned.Name)
 
 		if err != nil {
-			return fmt.Errorf("on creating dir: %v", err)
+			return nil, err
+		}
+
+		if exists {
+			planned.Generated, err = mergeRegions(file.Result, existing)
+
+			if err != nil {
+				return nil, fmt.Errorf("on merging protected regions of %s: %v", file.FinalPath, err)
+			}
+
+			planned.Content = planned.Generated
+
+			// Projects built before the manifest existed are overwritten, as they always were
+			planned.Conflict = previous != nil && b.options.OnConflict != ConflictOverwrite && previous.Modified(file.FinalPath, existing)
 		}
 
-		f, err := os.Create(fmt.Sprintf("%s/%s", projectPath, file.FinalPath))
+		if planned.Conflict && b.options.OnConflict == ConflictMerge {
+			base, _, err := readExisting(output, path.Join(projectPath, basePath, file.FinalPath))
+
+			if err != nil {
+				return nil, err
+			}
+
+			planned.Content, planned.Conflict = merge.Merge(base, existing, planned.Generated, merge.Labels{
+				Ours:   "current",
+				Theirs: "generated",
+			})
+			planned.Merged = !planned.Conflict
+		}
+
+		result = append(result, planned)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FinalPath < result[j].FinalPath
+	})
+
+	return result, nil
+}
+
+// Runs the post build steps of the strategy, in order, stopping at the first
+// that fails. Strategies that do not list their steps run as a single step.
+func (b *builder) runSteps(strategy entities.Strategy, projectPath string) ([]*StepResult, error) {
+	steps := []*entities.Step{{
+		Name: "post-actions",
+		Run: func(projectPath string, output io.Writer) error {
+			return strategy.BuildPostActions(projectPath)
+		},
+	}}
+
+	if stepped, ok := strategy.(entities.SteppedStrategy); ok {
+		var err error
+		steps, err = stepped.PostSteps()
 
 		if err != nil {
-			return fmt.Errorf("on creating file %s: %v", file.FinalPath, err)
+			return nil, err
 		}
+	}
+
+	results := make([]*StepResult, 0, len(steps))
 
-		defer f.Close()
+	for _, step := range steps {
+		b.emit(StepStarted{Name: step.Name})
 
-		_, err = f.WriteString(file.Result)
+		var output bytes.Buffer
+		start := time.Now()
+		err := step.Run(projectPath, &output)
+		result := &StepResult{
+			Name:     step.Name,
+			Duration: time.Since(start),
+			Output:   output.String(),
+			Err:      err,
+		}
+
+		results = append(results, result)
+		b.emit(StepFinished{result})
 
 		if err != nil {
-			return fmt.Errorf("on writing file %s: %v", file.FinalPath, err)
+			return results, fmt.Errorf("on step %s: %v", step.Name, err)
 		}
 	}
 
-	err = strategy.BuildPostActions(projectPath)
+	return results, nil
+}
 
-	if err != nil {
-		return fmt.Errorf("on build post actions: %s", err)
+func (b *builder) emit(event Event) {
+	if b.options.OnEvent == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.options.OnEvent(event)
+}
+
+type fileWrite struct {
+	name    string
+	content []byte
+	written func() // Called after the file is written, when not nil
+}
+
+// Writes the files concurrently when the output is a directory. Other outputs
+// get the files one by one, in order, so archives always list them the same way.
+func writeFiles(output Output, writes []*fileWrite) error {
+	write := func(i int) error {
+		err := output.WriteFile(writes[i].name, writes[i].content)
+
+		if err == nil && writes[i].written != nil {
+			writes[i].written()
+		}
+
+		return err
+	}
+
+	if _, ok := output.(DiskOutput); ok {
+		return parallel.Run(len(writes), write)
+	}
+
+	for i := range writes {
+		err := write(i)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func NewBuilder(outputFolder string) Builder {
-	return &builder{outputFolder}
+// Reads the file written by a previous build, if any
+func readExisting(output Output, name string) (string, bool, error) {
+	readable, ok := output.(ReadableOutput)
+
+	if !ok {
+		return "", false, nil
+	}
+
+	content, err := readable.ReadFile(name)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(content), true, nil
+}
+
+// Reads the base copy of a file of the project, what the last build generated
+func readBase(output Output, projectPath string, name string) ([]byte, error) {
+	content, exists, err := readExisting(output, path.Join(projectPath, basePath, name))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("on reading the base of %s: %w", name, fs.ErrNotExist)
+	}
+
+	return []byte(content), nil
+}
+
+// Reads a file just written, falling back to the written content when the output cannot be read
+func readBack(output Output, name string, written string) (string, error) {
+	content, exists, err := readExisting(output, name)
+
+	if err != nil || !exists {
+		return written, err
+	}
+
+	return content, nil
+}
+
+func NewBuilder(options *Options) Builder {
+	o := Options{}
+
+	if options != nil {
+		o = *options
+	}
+
+	if o.OnConflict == "" {
+		o.OnConflict = ConflictFail
+	}
+
+	return &builder{options: &o}
 }
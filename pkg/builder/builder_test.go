@@ -0,0 +1,426 @@
+package builder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type fakeStrategy struct {
+	fileMap     map[string]*entities.File
+	postActions []string
+}
+
+func (f *fakeStrategy) BuildFileMap() (map[string]*entities.File, error) {
+	return f.fileMap, nil
+}
+
+func (f *fakeStrategy) BuildPostActions(projectPath string) error {
+	f.postActions = append(f.postActions, projectPath)
+	return nil
+}
+
+func newFakeStrategy() *fakeStrategy {
+	return &fakeStrategy{
+		fileMap: map[string]*entities.File{
+			"main": {FinalPath: "main.go", Result: "package main\n"},
+			"user": {FinalPath: "pkg/user/service.go", Result: "package user\n"},
+		},
+	}
+}
+
+func newDefinitions() *entities.Definitions {
+	return &entities.Definitions{Id: "1", App: &entities.App{Name: "example"}}
+}
+
+var expectedFiles = map[string]string{
+	"1/example/main.go":             "package main\n",
+	"1/example/pkg/user/service.go": "package user\n",
+}
+
+func assertFiles(t *testing.T, actual map[string]string) {
+	for name := range actual {
+		if strings.Contains(name, "/"+StateFolder+"/") {
+			delete(actual, name)
+		}
+	}
+
+	if len(actual) != len(expectedFiles) {
+		t.Errorf("expected %d files, got %d: %v", len(expectedFiles), len(actual), actual)
+	}
+
+	for name, content := range expectedFiles {
+		if actual[name] != content {
+			t.Errorf("file %s wanted %q, got %q", name, content, actual[name])
+		}
+	}
+}
+
+func TestBuildDirOutput(t *testing.T) {
+	dir := t.TempDir()
+	stgy := newFakeStrategy()
+	_, err := NewBuilder(nil).Build(newDefinitions(), stgy, NewDirOutput(dir))
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	actual := make(map[string]string)
+
+	for name := range expectedFiles {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+
+		if err != nil {
+			t.Fatalf("reading %s: %s", name, err)
+		}
+
+		actual[name] = string(content)
+	}
+
+	assertFiles(t, actual)
+
+	if len(stgy.postActions) != 1 || stgy.postActions[0] != filepath.Join(dir, "1", "example") {
+		t.Errorf("post actions should run once in the project folder, got %v", stgy.postActions)
+	}
+}
+
+func TestBuildMemoryOutput(t *testing.T) {
+	stgy := newFakeStrategy()
+	output := NewMemoryOutput()
+	_, err := NewBuilder(nil).Build(newDefinitions(), stgy, output)
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	actual := make(map[string]string)
+	err = fs.WalkDir(output.FS(), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(output.FS(), name)
+		actual[name] = string(content)
+		return err
+	})
+
+	if err != nil {
+		t.Fatalf("walking output: %s", err)
+	}
+
+	assertFiles(t, actual)
+
+	if len(stgy.postActions) != 0 {
+		t.Errorf("post actions should not run for memory outputs")
+	}
+}
+
+func TestBuildZipOutput(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), NewZipOutput(&buf))
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	if err != nil {
+		t.Fatalf("reading zip: %s", err)
+	}
+
+	actual := make(map[string]string)
+
+	for _, file := range archive.File {
+		r, err := file.Open()
+
+		if err != nil {
+			t.Fatalf("opening %s: %s", file.Name, err)
+		}
+
+		content, err := io.ReadAll(r)
+		r.Close()
+
+		if err != nil {
+			t.Fatalf("reading %s: %s", file.Name, err)
+		}
+
+		actual[file.Name] = string(content)
+	}
+
+	assertFiles(t, actual)
+}
+
+func TestBuildTarGzOutput(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), NewTarGzOutput(&buf))
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+
+	if err != nil {
+		t.Fatalf("reading gzip: %s", err)
+	}
+
+	archive := tar.NewReader(gz)
+	actual := make(map[string]string)
+
+	for {
+		header, err := archive.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("reading tar: %s", err)
+		}
+
+		content, err := io.ReadAll(archive)
+
+		if err != nil {
+			t.Fatalf("reading %s: %s", header.Name, err)
+		}
+
+		actual[header.Name] = string(content)
+	}
+
+	assertFiles(t, actual)
+}
+
+// steppedStrategy lists its post build steps, the last one failing when fail is set
+type steppedStrategy struct {
+	*fakeStrategy
+	fail bool
+}
+
+func (s *steppedStrategy) PostSteps() ([]*entities.Step, error) {
+	return []*entities.Step{
+		{Name: "format", Run: func(projectPath string, output io.Writer) error {
+			fmt.Fprintln(output, "formatted main.go")
+			return nil
+		}},
+		{Name: "test", Run: func(projectPath string, output io.Writer) error {
+			fmt.Fprintln(output, "FAIL example/user")
+
+			if s.fail {
+				return fmt.Errorf("exit status 1")
+			}
+
+			return nil
+		}},
+	}, nil
+}
+
+func TestBuildEvents(t *testing.T) {
+	events := make([]string, 0)
+	b := NewBuilder(&Options{OnEvent: func(event Event) {
+		switch e := event.(type) {
+		case FileRendered:
+			events = append(events, "rendered "+e.Path)
+		case FileWritten:
+			events = append(events, "written "+e.Path)
+		case StepStarted:
+			events = append(events, "started "+e.Name)
+		case StepFinished:
+			events = append(events, fmt.Sprintf("finished %s: %v", e.Name, e.Err))
+		}
+	}})
+
+	report, err := b.Build(newDefinitions(), &steppedStrategy{newFakeStrategy(), true}, NewDirOutput(t.TempDir()))
+
+	if err == nil || err.Error() != "on build post actions: on step test: exit status 1" {
+		t.Fatalf("expected the error of the test step, got %v", err)
+	}
+
+	// Files are written concurrently, so only the order of the other events is known
+	sort.Strings(events[2:4])
+	expected := []string{
+		"rendered main.go",
+		"rendered pkg/user/service.go",
+		"written main.go",
+		"written pkg/user/service.go",
+		"started format",
+		"finished format: <nil>",
+		"started test",
+		"finished test: exit status 1",
+	}
+
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected events:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(events, "\n"))
+	}
+
+	if report == nil || len(report.Files) != 2 || report.Files[0].Path != "main.go" || report.Files[0].Size != len("package main\n") {
+		t.Fatalf("the report should list the written files, got %+v", report)
+	}
+
+	if len(report.Steps) != 2 || report.Steps[0].Output != "formatted main.go\n" || report.Steps[1].Err == nil {
+		t.Fatalf("the report should have the output and the error of every step, got %+v", report.Steps)
+	}
+}
+
+// progressStrategy renders its files one by one, logging each one before reporting it
+type progressStrategy struct {
+	*fakeStrategy
+	log      *[]string
+	rendered func(*entities.File)
+}
+
+func (s *progressStrategy) SetOnRendered(rendered func(file *entities.File)) {
+	s.rendered = rendered
+}
+
+func (s *progressStrategy) BuildFileMap() (map[string]*entities.File, error) {
+	for _, key := range []string{"main", "user"} {
+		file := s.fileMap[key]
+		*s.log = append(*s.log, "rendering "+file.FinalPath)
+		s.rendered(file)
+	}
+
+	return s.fileMap, nil
+}
+
+func TestBuildProgressEvents(t *testing.T) {
+	log := make([]string, 0)
+	b := NewBuilder(&Options{OnEvent: func(event Event) {
+		if e, ok := event.(FileRendered); ok {
+			log = append(log, "rendered "+e.Path)
+		}
+	}})
+
+	_, err := b.Build(newDefinitions(), &progressStrategy{fakeStrategy: newFakeStrategy(), log: &log}, NewDirOutput(t.TempDir()))
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	// Every file is reported as it is rendered, and only once
+	expected := []string{
+		"rendering main.go",
+		"rendered main.go",
+		"rendering pkg/user/service.go",
+		"rendered pkg/user/service.go",
+	}
+
+	if strings.Join(log, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(log, "\n"))
+	}
+}
+
+func TestBuildPostActionsStep(t *testing.T) {
+	report, err := NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), NewDirOutput(t.TempDir()))
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	if len(report.Steps) != 1 || report.Steps[0].Name != "post-actions" || report.Steps[0].Err != nil {
+		t.Fatalf("the post actions of strategies without steps should run as a single step, got %+v", report.Steps)
+	}
+}
+
+func TestBuildReproducibleArchives(t *testing.T) {
+	archives := map[string]func(io.Writer) Output{
+		"zip":    NewZipOutput,
+		"tar.gz": NewTarGzOutput,
+	}
+
+	for name, newOutput := range archives {
+		t.Run(name, func(t *testing.T) {
+			var first, second bytes.Buffer
+
+			for _, buf := range []*bytes.Buffer{&first, &second} {
+				_, err := NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), newOutput(buf))
+
+				if err != nil {
+					t.Fatalf("Build returned an error: %s", err)
+				}
+			}
+
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Errorf("building the same project twice should give the same archive")
+			}
+		})
+	}
+}
+
+func BenchmarkBuildDirOutput(b *testing.B) {
+	stgy := &fakeStrategy{fileMap: make(map[string]*entities.File)}
+
+	for i := 0; i < 1000; i++ {
+		stgy.fileMap[fmt.Sprintf("entity%d", i)] = &entities.File{
+			FinalPath: fmt.Sprintf("pkg/entity%d/service.go", i),
+			Result:    strings.Repeat(fmt.Sprintf("// entity %d\n", i), 200),
+		}
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := NewBuilder(nil).Build(newDefinitions(), stgy, NewDirOutput(b.TempDir()))
+
+		if err != nil {
+			b.Fatalf("Build returned an error: %s", err)
+		}
+	}
+}
+
+// incrementalStrategy records what its main file had in the last build
+type incrementalStrategy struct {
+	*fakeStrategy
+	previous []string
+}
+
+func (s *incrementalStrategy) SetPrevious(read func(name string) ([]byte, error)) {
+	content, err := read("main.go")
+
+	if errors.Is(err, fs.ErrNotExist) {
+		s.previous = append(s.previous, "missing")
+		return
+	}
+
+	s.previous = append(s.previous, string(content))
+}
+
+func TestBuildIncrementalStrategy(t *testing.T) {
+	dir := t.TempDir()
+	stgy := &incrementalStrategy{fakeStrategy: newFakeStrategy()}
+
+	for i := 0; i < 2; i++ {
+		if _, err := NewBuilder(nil).Build(newDefinitions(), stgy, NewDirOutput(dir)); err != nil {
+			t.Fatalf("Build returned an error: %s", err)
+		}
+	}
+
+	// The user changes are not what the last build generated
+	if err := os.WriteFile(filepath.Join(dir, "1", "example", "main.go"), []byte("package main\n\n// edited\n"), 0644); err != nil {
+		t.Fatalf("editing main.go: %s", err)
+	}
+
+	stgy.fileMap["main"].Result = "package main\n\nfunc main() {}\n"
+
+	if _, err := NewBuilder(&Options{OnConflict: ConflictSideFile}).Build(newDefinitions(), stgy, NewDirOutput(dir)); err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	expected := []string{"missing", "package main\n", "package main\n"}
+
+	if strings.Join(stgy.previous, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected previous contents %q, got %q", expected, stgy.previous)
+	}
+}
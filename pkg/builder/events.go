@@ -0,0 +1,37 @@
+package builder
+
+// Event - Progress of a build, one of FileRendered, FileWritten, StepStarted
+// and StepFinished
+type Event interface {
+	event()
+}
+
+// FileRendered - A file of the project was rendered by the strategy. The events
+// are sent as the files are rendered when the strategy is an
+// entities.ProgressStrategy, in no particular order, otherwise once it
+// rendered all the files, sorted by path.
+type FileRendered struct {
+	Path     string // Path in the project
+	Template string
+}
+
+// FileWritten - A file of the project was written to the output. Files can be
+// written concurrently, so the events are not sorted.
+type FileWritten struct {
+	*FileResult
+}
+
+// StepStarted - A post build step started
+type StepStarted struct {
+	Name string
+}
+
+// StepFinished - A post build step finished, successfully or not
+type StepFinished struct {
+	*StepResult
+}
+
+func (FileRendered) event() {}
+func (FileWritten) event()  {}
+func (StepStarted) event()  {}
+func (StepFinished) event() {}
@@ -0,0 +1,120 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// Folder, inside the generated project, where the builder keeps its state
+	StateFolder = ".fancybuild"
+
+	manifestPath    = StateFolder + "/manifest.json"
+	basePath        = StateFolder + "/base" // Copies of the generated files, used as the base of merges
+	manifestVersion = 1
+)
+
+// Manifest - Lists the files of the last build of a project, with a hash of
+// their content. It is used to find the files the user changed since then.
+type Manifest struct {
+	Version int                      `json:"version"`
+	Files   map[string]*ManifestFile `json:"files"` // Keyed by the path of the file in the project
+}
+
+type ManifestFile struct {
+	Template string `json:"template"`
+	Hash     string `json:"hash"`
+}
+
+// Hashes the content of a generated file. The content of the protected regions
+// is left out, since it belongs to the user and is kept on regeneration.
+func hashContent(content string) string {
+	lines := strings.Split(content, "\n")
+	regions, err := parseRegions(lines)
+
+	if err == nil {
+		for i := len(regions) - 1; i >= 0; i-- {
+			lines = append(lines[:regions[i].Start], lines[regions[i].End:]...)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Checks if a file of the project was changed since the build that generated the manifest
+func (m *Manifest) Modified(name string, content string) bool {
+	file, ok := m.Files[name]
+	return !ok || file.Hash != hashContent(content)
+}
+
+// Returns the files of the manifest that are not part of the file map, sorted by path
+func (m *Manifest) Stale(paths map[string]bool) []string {
+	result := make([]string, 0)
+
+	for name := range m.Files {
+		if !paths[name] {
+			result = append(result, name)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// Reads the manifest of the last build. Returns nil when the project was never
+// built or the output cannot be read.
+func readManifest(output Output, projectPath string) (*Manifest, error) {
+	readable, ok := output.(ReadableOutput)
+
+	if !ok {
+		return nil, nil
+	}
+
+	content, err := readable.ReadFile(path.Join(projectPath, manifestPath))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("on reading manifest: %v", err)
+	}
+
+	var manifest Manifest
+	err = json.Unmarshal(content, &manifest)
+
+	if err != nil {
+		return nil, fmt.Errorf("on parsing manifest %s: %v", manifestPath, err)
+	}
+
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]*ManifestFile)
+	}
+
+	return &manifest, nil
+}
+
+func writeManifest(output Output, projectPath string, manifest *Manifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("on encoding manifest: %v", err)
+	}
+
+	return output.WriteFile(path.Join(projectPath, manifestPath), append(content, '\n'))
+}
+
+func newManifest() *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+		Files:   make(map[string]*ManifestFile),
+	}
+}
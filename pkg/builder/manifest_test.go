@@ -0,0 +1,227 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func buildTwice(t *testing.T, options *Options, edit func(output *MemoryOutput), second *fakeStrategy) (*MemoryOutput, *BuildReport, error) {
+	output := NewMemoryOutput()
+	_, err := NewBuilder(options).Build(newDefinitions(), newFakeStrategy(), output)
+
+	if err != nil {
+		t.Fatalf("first Build returned an error: %s", err)
+	}
+
+	edit(output)
+	report, err := NewBuilder(options).Build(newDefinitions(), second, output)
+	return output, report, err
+}
+
+func readString(t *testing.T, output *MemoryOutput, name string) string {
+	content, err := output.ReadFile(name)
+
+	if err != nil {
+		t.Fatalf("reading %s: %s", name, err)
+	}
+
+	return string(content)
+}
+
+func editMain(t *testing.T) func(*MemoryOutput) {
+	return func(output *MemoryOutput) {
+		err := output.WriteFile("1/example/main.go", []byte("package main\n\n// edited\n"))
+
+		if err != nil {
+			t.Fatalf("editing main.go: %s", err)
+		}
+	}
+}
+
+func TestBuildWithoutChanges(t *testing.T) {
+	second := newFakeStrategy()
+	second.fileMap["main"].Result = "package main\n\nfunc main() {}\n"
+	delete(second.fileMap, "user")
+	output, report, err := buildTwice(t, nil, func(*MemoryOutput) {}, second)
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	if len(report.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", report.Conflicts)
+	}
+
+	if !reflect.DeepEqual(report.Stale, []string{"pkg/user/service.go"}) {
+		t.Errorf("expected pkg/user/service.go to be stale, got %v", report.Stale)
+	}
+
+	if readString(t, output, "1/example/main.go") != second.fileMap["main"].Result {
+		t.Errorf("unchanged files should be overwritten")
+	}
+}
+
+func TestBuildConflictFail(t *testing.T) {
+	second := newFakeStrategy()
+	second.fileMap["user"].Result = "package user\n\n// new\n"
+	output, report, err := buildTwice(t, nil, editMain(t), second)
+
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(report.Conflicts, []string{"main.go"}) {
+		t.Errorf("expected main.go to conflict, got %v", report.Conflicts)
+	}
+
+	if readString(t, output, "1/example/pkg/user/service.go") != "package user\n" {
+		t.Errorf("no files should be written when the build is refused")
+	}
+}
+
+func TestBuildConflictSideFile(t *testing.T) {
+	options := &Options{OnConflict: ConflictSideFile}
+	output, report, err := buildTwice(t, options, editMain(t), newFakeStrategy())
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	if !reflect.DeepEqual(report.Conflicts, []string{"main.go"}) {
+		t.Errorf("expected main.go to conflict, got %v", report.Conflicts)
+	}
+
+	if readString(t, output, "1/example/main.go") != "package main\n\n// edited\n" {
+		t.Errorf("the user file should be kept")
+	}
+
+	if readString(t, output, "1/example/main.go"+SideFileSuffix) != "package main\n" {
+		t.Errorf("the new file should be written next to the user file")
+	}
+
+	// The user changes are still detected on the next build
+	_, err = NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), output)
+
+	if _, ok := err.(*ConflictError); !ok {
+		t.Errorf("expected a conflict error on the next build, got %v", err)
+	}
+}
+
+func TestBuildConflictOverwrite(t *testing.T) {
+	options := &Options{OnConflict: ConflictOverwrite}
+	output, report, err := buildTwice(t, options, editMain(t), newFakeStrategy())
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	if len(report.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", report.Conflicts)
+	}
+
+	if readString(t, output, "1/example/main.go") != "package main\n" {
+		t.Errorf("the user file should be overwritten")
+	}
+}
+
+func TestHashContentIgnoresRegions(t *testing.T) {
+	withCode := "a\n// fancybuild:begin x\ncode()\n// fancybuild:end x\nb"
+	empty := "a\n// fancybuild:begin x\n// fancybuild:end x\nb"
+
+	if hashContent(withCode) != hashContent(empty) {
+		t.Errorf("the content of protected regions should not change the hash")
+	}
+
+	if hashContent(empty) == hashContent("a\nb") {
+		t.Errorf("removing a region should change the hash")
+	}
+}
+
+func TestBuildConflictMerge(t *testing.T) {
+	options := &Options{OnConflict: ConflictMerge}
+	first := newFakeStrategy()
+	first.fileMap["main"].Result = "package main\n\nfunc main() {\n\tstart()\n}\n"
+	output := NewMemoryOutput()
+	_, err := NewBuilder(options).Build(newDefinitions(), first, output)
+
+	if err != nil {
+		t.Fatalf("first Build returned an error: %s", err)
+	}
+
+	err = output.WriteFile("1/example/main.go", []byte("// Command example\npackage main\n\nfunc main() {\n\tstart()\n}\n"))
+
+	if err != nil {
+		t.Fatalf("editing main.go: %s", err)
+	}
+
+	second := newFakeStrategy()
+	second.fileMap["main"].Result = "package main\n\nfunc main() {\n\tstart()\n\twait()\n}\n"
+	report, err := NewBuilder(options).Build(newDefinitions(), second, output)
+
+	if err != nil {
+		t.Fatalf("second Build returned an error: %s", err)
+	}
+
+	if !reflect.DeepEqual(report.Merged, []string{"main.go"}) || len(report.Conflicts) != 0 {
+		t.Errorf("expected main.go to be merged without conflicts, got %+v", report)
+	}
+
+	expected := "// Command example\npackage main\n\nfunc main() {\n\tstart()\n\twait()\n}\n"
+
+	if actual := readString(t, output, "1/example/main.go"); actual != expected {
+		t.Errorf("merged file wanted:\n%s\nBut got:\n%s", expected, actual)
+	}
+
+	// The user changes conflict with the next version
+	err = output.WriteFile("1/example/main.go", []byte("// Command example\npackage main\n\nfunc main() {\n\tstart()\n\tlisten()\n}\n"))
+
+	if err != nil {
+		t.Fatalf("editing main.go: %s", err)
+	}
+
+	third := newFakeStrategy()
+	third.fileMap["main"].Result = "package main\n\nfunc main() {\n\tstart()\n\tserve()\n}\n"
+	report, err = NewBuilder(options).Build(newDefinitions(), third, output)
+
+	if err != nil {
+		t.Fatalf("third Build returned an error: %s", err)
+	}
+
+	if !reflect.DeepEqual(report.Conflicts, []string{"main.go"}) {
+		t.Errorf("expected main.go to be merged with conflicts, got %+v", report)
+	}
+
+	expected = "// Command example\npackage main\n\nfunc main() {\n\tstart()\n<<<<<<< current\n\tlisten()\n=======\n\tserve()\n>>>>>>> generated\n}\n"
+
+	if actual := readString(t, output, "1/example/main.go"); actual != expected {
+		t.Errorf("merged file wanted:\n%s\nBut got:\n%s", expected, actual)
+	}
+}
+
+func TestBuildManifestWithFailingStep(t *testing.T) {
+	dir := t.TempDir()
+	report, err := NewBuilder(nil).Build(newDefinitions(), &steppedStrategy{newFakeStrategy(), true}, NewDirOutput(dir))
+
+	if err == nil {
+		t.Fatalf("expected the error of the failing step")
+	}
+
+	if report.Files[0].Hash != hashContent("package main\n") {
+		t.Errorf("the report should have the hashes of the manifest, got %+v", report.Files[0])
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "1", "example", "main.go"), []byte("package main\n\n// edited\n"), 0644)
+
+	if err != nil {
+		t.Fatalf("editing main.go: %s", err)
+	}
+
+	// The edit is only detected when the manifest was written by the first build
+	_, err = NewBuilder(nil).Build(newDefinitions(), newFakeStrategy(), NewDirOutput(dir))
+
+	if _, ok := err.(*ConflictError); !ok {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+}
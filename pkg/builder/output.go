@@ -0,0 +1,213 @@
+package builder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// Output - Destination of the generated project files
+type Output interface {
+	// Writes a file. The name is a slash separated path relative to the root of the output.
+	WriteFile(name string, content []byte) error
+	// Finishes the output. No files can be written after it.
+	Close() error
+}
+
+// DiskOutput - An output stored in a directory of the local disk. It is the only
+// kind of output where the post actions of the strategy can run.
+type DiskOutput interface {
+	Output
+	Dir() string
+}
+
+// ReadableOutput - An output that can read back the files of a previous build.
+// ReadFile returns an error wrapping fs.ErrNotExist when the file does not exist.
+type ReadableOutput interface {
+	Output
+	ReadFile(name string) ([]byte, error)
+}
+
+type dirOutput struct {
+	dir string
+}
+
+func (d *dirOutput) WriteFile(name string, content []byte) error {
+	path := filepath.Join(d.dir, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(path), 0744)
+
+	if err != nil {
+		return fmt.Errorf("on creating dir: %v", err)
+	}
+
+	err = os.WriteFile(path, content, 0644)
+
+	if err != nil {
+		return fmt.Errorf("on writing file %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func (d *dirOutput) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.dir, filepath.FromSlash(name)))
+}
+
+func (d *dirOutput) Close() error {
+	return nil
+}
+
+func (d *dirOutput) Dir() string {
+	return d.dir
+}
+
+// NewDirOutput - Writes the files to a directory of the local disk
+func NewDirOutput(dir string) DiskOutput {
+	return &dirOutput{dir}
+}
+
+// MemoryOutput - Keeps the files in memory, exposing them as a fs.FS
+type MemoryOutput struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func (m *MemoryOutput) WriteFile(name string, content []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[name] = &fstest.MapFile{
+		Data: content,
+		Mode: 0644,
+	}
+
+	return nil
+}
+
+func (m *MemoryOutput) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fs.ReadFile(m.files, name)
+}
+
+func (m *MemoryOutput) Close() error {
+	return nil
+}
+
+// FS - Returns the files written so far
+func (m *MemoryOutput) FS() fs.FS {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(fstest.MapFS, len(m.files))
+
+	for name, file := range m.files {
+		result[name] = file
+	}
+
+	return result
+}
+
+func NewMemoryOutput() *MemoryOutput {
+	return &MemoryOutput{files: make(fstest.MapFS)}
+}
+
+// Modification time of the archive entries. It is fixed, so building the same
+// project twice gives the same archive.
+var archiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type zipOutput struct {
+	mu     sync.Mutex
+	writer *zip.Writer
+}
+
+func (z *zipOutput) WriteFile(name string, content []byte) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: archiveTime,
+	}
+	header.SetMode(0644)
+
+	w, err := z.writer.CreateHeader(header)
+
+	if err != nil {
+		return fmt.Errorf("on creating zip entry %s: %v", name, err)
+	}
+
+	_, err = w.Write(content)
+
+	if err != nil {
+		return fmt.Errorf("on writing zip entry %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func (z *zipOutput) Close() error {
+	return z.writer.Close()
+}
+
+// NewZipOutput - Streams the files as a zip archive
+func NewZipOutput(w io.Writer) Output {
+	return &zipOutput{writer: zip.NewWriter(w)}
+}
+
+type tarGzOutput struct {
+	mu     sync.Mutex
+	gzip   *gzip.Writer
+	writer *tar.Writer
+}
+
+func (t *tarGzOutput) WriteFile(name string, content []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  archiveTime,
+	})
+
+	if err != nil {
+		return fmt.Errorf("on creating tar entry %s: %v", name, err)
+	}
+
+	_, err = t.writer.Write(content)
+
+	if err != nil {
+		return fmt.Errorf("on writing tar entry %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func (t *tarGzOutput) Close() error {
+	err := t.writer.Close()
+
+	if err != nil {
+		return err
+	}
+
+	return t.gzip.Close()
+}
+
+// NewTarGzOutput - Streams the files as a gzip compressed tar archive
+func NewTarGzOutput(w io.Writer) Output {
+	gz := gzip.NewWriter(w)
+	return &tarGzOutput{gzip: gz, writer: tar.NewWriter(gz)}
+}
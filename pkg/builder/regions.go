@@ -0,0 +1,126 @@
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Protected regions are blocks of a generated file, delimited by the markers
+// below, that belong to the user. Their content is carried over from the
+// existing file every time the project is regenerated. E.g.:
+//
+//	// fancybuild:begin custom-create
+//	... hand-written code ...
+//	// fancybuild:end custom-create
+var (
+	regionBeginPattern = regexp.MustCompile(`fancybuild:begin ([\w.-]+)`)
+	regionEndPattern   = regexp.MustCompile(`fancybuild:end ([\w.-]+)`)
+)
+
+type region struct {
+	Name  string
+	Start int // Index of the first line inside the region
+	End   int // Index of the end marker line
+}
+
+// Finds the protected regions of a file, in the order they appear
+func parseRegions(lines []string) ([]*region, error) {
+	result := make([]*region, 0)
+	names := make(map[string]bool)
+	var current *region
+
+	for i, line := range lines {
+		if match := regionBeginPattern.FindStringSubmatch(line); match != nil {
+			if current != nil {
+				return nil, fmt.Errorf("line %d: region %s starts inside region %s", i+1, match[1], current.Name)
+			}
+
+			if names[match[1]] {
+				return nil, fmt.Errorf("line %d: region %s is declared twice", i+1, match[1])
+			}
+
+			names[match[1]] = true
+			current = &region{Name: match[1], Start: i + 1}
+			continue
+		}
+
+		if match := regionEndPattern.FindStringSubmatch(line); match != nil {
+			if current == nil || current.Name != match[1] {
+				return nil, fmt.Errorf("line %d: unexpected end of region %s", i+1, match[1])
+			}
+
+			current.End = i
+			result = append(result, current)
+			current = nil
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("region %s is not closed", current.Name)
+	}
+
+	return result, nil
+}
+
+// Copies the content of the protected regions of the existing file into the
+// freshly generated one. Fails when the existing file has a non-empty region
+// that is not generated anymore, instead of dropping the hand-written code.
+func mergeRegions(generated string, existing string) (string, error) {
+	existingLines := strings.Split(existing, "\n")
+	existingRegions, err := parseRegions(existingLines)
+
+	if err != nil {
+		return "", fmt.Errorf("existing file: %v", err)
+	}
+
+	if len(existingRegions) == 0 {
+		return generated, nil
+	}
+
+	generatedLines := strings.Split(generated, "\n")
+	generatedRegions, err := parseRegions(generatedLines)
+
+	if err != nil {
+		return "", fmt.Errorf("generated file: %v", err)
+	}
+
+	bodies := make(map[string][]string)
+
+	for _, r := range existingRegions {
+		bodies[r.Name] = existingLines[r.Start:r.End]
+	}
+
+	result := make([]string, 0, len(generatedLines))
+	last := 0
+
+	for _, r := range generatedRegions {
+		result = append(result, generatedLines[last:r.Start]...)
+
+		if body, ok := bodies[r.Name]; ok {
+			result = append(result, body...)
+			delete(bodies, r.Name)
+		} else {
+			result = append(result, generatedLines[r.Start:r.End]...)
+		}
+
+		last = r.End
+	}
+
+	result = append(result, generatedLines[last:]...)
+	orphans := make([]string, 0)
+
+	for name, body := range bodies {
+		if strings.TrimSpace(strings.Join(body, "")) != "" {
+			orphans = append(orphans, name)
+		}
+	}
+
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		return "", fmt.Errorf("protected regions %s are not generated anymore, move their code before regenerating", strings.Join(orphans, ", "))
+	}
+
+	return strings.Join(result, "\n"), nil
+}
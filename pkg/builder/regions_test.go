@@ -0,0 +1,143 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+)
+
+type mergeRegionsTestCase struct {
+	Description   string
+	Generated     string
+	Existing      string
+	Expected      string
+	ExpectedError bool
+}
+
+const regionsGenerated = `package user
+
+import (
+	// fancybuild:begin custom-imports
+	// fancybuild:end custom-imports
+)
+
+func Create() {
+	// fancybuild:begin custom-create
+	// fancybuild:end custom-create
+	save()
+}`
+
+func TestMergeRegions(t *testing.T) {
+	testCases := []*mergeRegionsTestCase{
+		{
+			Description: "existing file without regions",
+			Generated:   regionsGenerated,
+			Existing:    "package user\n",
+			Expected:    regionsGenerated,
+		},
+		{
+			Description: "keeps the content of the existing regions",
+			Generated:   strings.Replace(regionsGenerated, "save()", "repository.Save()", 1),
+			Existing: `package user
+
+import (
+// fancybuild:begin custom-imports
+"strings"
+// fancybuild:end custom-imports
+)
+
+func Create() {
+	// fancybuild:begin custom-create
+	name = strings.TrimSpace(name)
+	// fancybuild:end custom-create
+	save()
+}`,
+			Expected: `package user
+
+import (
+	// fancybuild:begin custom-imports
+"strings"
+	// fancybuild:end custom-imports
+)
+
+func Create() {
+	// fancybuild:begin custom-create
+	name = strings.TrimSpace(name)
+	// fancybuild:end custom-create
+	repository.Save()
+}`,
+		},
+		{
+			Description: "empty region that is not generated anymore",
+			Generated:   "package user\n",
+			Existing:    "// fancybuild:begin custom-delete\n// fancybuild:end custom-delete\n",
+			Expected:    "package user\n",
+		},
+		{
+			Description:   "region with code that is not generated anymore",
+			Generated:     "package user\n",
+			Existing:      "// fancybuild:begin custom-delete\nlog()\n// fancybuild:end custom-delete\n",
+			ExpectedError: true,
+		},
+		{
+			Description:   "existing region not closed",
+			Generated:     regionsGenerated,
+			Existing:      "// fancybuild:begin custom-create\n",
+			ExpectedError: true,
+		},
+		{
+			Description:   "existing region declared twice",
+			Generated:     regionsGenerated,
+			Existing:      "// fancybuild:begin a\n// fancybuild:end a\n// fancybuild:begin a\n// fancybuild:end a\n",
+			ExpectedError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			result, err := mergeRegions(testCase.Generated, testCase.Existing)
+
+			if testCase.ExpectedError {
+				if err == nil {
+					t.Errorf("expected an error, got:\n%s", result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if result != testCase.Expected {
+				t.Errorf("mergeRegions wanted:\n%s\nBut got:\n%s", testCase.Expected, result)
+			}
+		})
+	}
+}
+
+func TestBuildKeepsProtectedRegions(t *testing.T) {
+	stgy := newFakeStrategy()
+	stgy.fileMap["user"].Result = regionsGenerated
+	output := NewMemoryOutput()
+	custom := strings.Replace(regionsGenerated, "\t// fancybuild:end custom-create", "\tvalidate()\n\t// fancybuild:end custom-create", 1)
+	err := output.WriteFile("1/example/pkg/user/service.go", []byte(custom))
+
+	if err != nil {
+		t.Fatalf("writing file: %s", err)
+	}
+
+	_, err = NewBuilder(nil).Build(newDefinitions(), stgy, output)
+
+	if err != nil {
+		t.Fatalf("Build returned an error: %s", err)
+	}
+
+	content, err := output.ReadFile("1/example/pkg/user/service.go")
+
+	if err != nil {
+		t.Fatalf("reading file: %s", err)
+	}
+
+	if string(content) != custom {
+		t.Errorf("Build should keep the protected regions, wanted:\n%s\nBut got:\n%s", custom, content)
+	}
+}
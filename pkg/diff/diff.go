@@ -0,0 +1,298 @@
+// Package diff compares two versions of a definition and produces a typed
+// change plan, classifying each change by its impact on API consumers and on
+// the data already stored by the generated application.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Kind of a change
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Renamed Kind = "renamed"
+	Changed Kind = "changed"
+)
+
+// Part of the definitions a change applies to
+type Subject string
+
+const (
+	SubjectEntity       Subject = "entity"
+	SubjectField        Subject = "field"
+	SubjectAction       Subject = "action"
+	SubjectRelationship Subject = "relationship"
+	SubjectIndex        Subject = "index"
+)
+
+// Impact of a change on API consumers or on stored data
+type Impact string
+
+const (
+	None     Impact = "none"     // The change is not visible
+	Additive Impact = "additive" // Existing consumers or data keep working
+	Breaking Impact = "breaking" // Existing consumers or data must be migrated
+)
+
+// A single difference between two definitions
+type Change struct {
+	Kind    Kind    `json:"kind"`
+	Subject Subject `json:"subject"`
+	Entity  string  `json:"entity,omitempty"`  // Entity the field, action or index belongs to
+	Name    string  `json:"name"`              // Name of the changed item, the new name for renames
+	OldName string  `json:"oldName,omitempty"` // Previous name, for renames
+	Detail  string  `json:"detail,omitempty"`  // What changed, for changed items
+	API     Impact  `json:"api"`
+	Storage Impact  `json:"storage"`
+}
+
+// Checks if the change breaks API consumers or stored data
+func (c Change) IsBreaking() bool {
+	return c.API == Breaking || c.Storage == Breaking
+}
+
+// Describes the change in a single line, e.g. "renamed field user.mail to email"
+func (c Change) String() string {
+	name := c.Name
+
+	if c.Entity != "" {
+		name = fmt.Sprintf("%s.%s", c.Entity, c.Name)
+	}
+
+	var description string
+
+	switch c.Kind {
+	case Renamed:
+		old := c.OldName
+		if c.Entity != "" {
+			old = fmt.Sprintf("%s.%s", c.Entity, c.OldName)
+		}
+		description = fmt.Sprintf("renamed %s %s to %s", c.Subject, old, c.Name)
+	case Changed:
+		description = fmt.Sprintf("changed %s %s: %s", c.Subject, name, c.Detail)
+	default:
+		description = fmt.Sprintf("%s %s %s", c.Kind, c.Subject, name)
+	}
+
+	return fmt.Sprintf("%s (api: %s, storage: %s)", description, c.API, c.Storage)
+}
+
+// The changes between two definitions, in a stable order
+type Plan struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Checks if any change breaks API consumers or stored data
+func (p Plan) IsBreaking() bool {
+	for _, change := range p.Changes {
+		if change.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns the changes needed to go from the old definitions to the new ones.
+// Removed and added items with the same shape are reported as renames.
+func Compare(old *entities.Definitions, next *entities.Definitions) *Plan {
+	c := &comparison{plan: &Plan{Changes: make([]*Change, 0)}, renames: make(map[string]string)}
+
+	oldEntities := entityMap(old)
+	newEntities := entityMap(next)
+
+	c.compareEntities(old, next, oldEntities, newEntities)
+	c.compareRelationships(old, next)
+
+	return c.plan
+}
+
+type comparison struct {
+	plan    *Plan
+	renames map[string]string // Old entity name to the new one
+}
+
+func (c *comparison) add(change *Change) {
+	c.plan.Changes = append(c.plan.Changes, change)
+}
+
+func entityMap(definitions *entities.Definitions) map[string]*entities.Entity {
+	result := make(map[string]*entities.Entity)
+
+	if definitions == nil || definitions.App == nil {
+		return result
+	}
+
+	for _, entity := range definitions.App.Entities {
+		result[entity.Name] = entity
+	}
+
+	return result
+}
+
+func (c *comparison) compareEntities(old, next *entities.Definitions, oldEntities, newEntities map[string]*entities.Entity) {
+	removed := make([]*entities.Entity, 0)
+	added := make([]*entities.Entity, 0)
+
+	for _, entity := range sortedEntities(oldEntities) {
+		if _, ok := newEntities[entity.Name]; !ok {
+			removed = append(removed, entity)
+		}
+	}
+
+	for _, entity := range sortedEntities(newEntities) {
+		if _, ok := oldEntities[entity.Name]; !ok {
+			added = append(added, entity)
+		}
+	}
+
+	// An entity removed and another added with the same fields is a rename
+	for _, oldEntity := range removed {
+		match := -1
+
+		for i, newEntity := range added {
+			if newEntity != nil && entitySignature(oldEntity) == entitySignature(newEntity) {
+				if match >= 0 {
+					match = -1
+					break
+				}
+				match = i
+			}
+		}
+
+		if match < 0 {
+			continue
+		}
+
+		newEntity := added[match]
+		added[match] = nil
+		c.renames[oldEntity.Name] = newEntity.Name
+
+		c.add(&Change{
+			Kind:    Renamed,
+			Subject: SubjectEntity,
+			Name:    newEntity.Name,
+			OldName: oldEntity.Name,
+			API:     Breaking,
+			Storage: persistedImpact(oldEntity, Breaking),
+		})
+	}
+
+	for _, entity := range removed {
+		if _, ok := c.renames[entity.Name]; ok {
+			continue
+		}
+
+		c.add(&Change{
+			Kind:    Removed,
+			Subject: SubjectEntity,
+			Name:    entity.Name,
+			API:     Breaking,
+			Storage: persistedImpact(entity, Breaking),
+		})
+	}
+
+	for _, entity := range added {
+		if entity == nil {
+			continue
+		}
+
+		c.add(&Change{
+			Kind:    Added,
+			Subject: SubjectEntity,
+			Name:    entity.Name,
+			API:     Additive,
+			Storage: persistedImpact(entity, Additive),
+		})
+	}
+
+	for _, oldEntity := range sortedEntities(oldEntities) {
+		name := oldEntity.Name
+
+		if renamed, ok := c.renames[name]; ok {
+			name = renamed
+		}
+
+		if newEntity, ok := newEntities[name]; ok {
+			c.compareEntity(oldEntity, newEntity)
+		}
+	}
+}
+
+func sortedEntities(m map[string]*entities.Entity) []*entities.Entity {
+	result := make([]*entities.Entity, 0, len(m))
+
+	for _, entity := range m {
+		result = append(result, entity)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func entitySignature(entity *entities.Entity) string {
+	fields := make([]string, 0, len(entity.Fields))
+
+	for _, field := range entity.Fields {
+		fields = append(fields, field.Name+":"+field.Type)
+	}
+
+	sort.Strings(fields)
+
+	return strings.Join(fields, ",")
+}
+
+// Only persisted entities have stored data
+func persistedImpact(entity *entities.Entity, impact Impact) Impact {
+	if !entity.Persisted {
+		return None
+	}
+	return impact
+}
+
+func (c *comparison) compareEntity(old, next *entities.Entity) {
+	if old.Persisted != next.Persisted {
+		storage := Additive
+		if old.Persisted {
+			storage = Breaking
+		}
+
+		c.add(&Change{
+			Kind:    Changed,
+			Subject: SubjectEntity,
+			Name:    next.Name,
+			Detail:  fmt.Sprintf("persisted from %v to %v", old.Persisted, next.Persisted),
+			API:     None,
+			Storage: storage,
+		})
+	}
+
+	if old.Timestamps != next.Timestamps {
+		api, storage := Additive, Additive
+		if old.Timestamps {
+			api, storage = Breaking, None
+		}
+
+		c.add(&Change{
+			Kind:    Changed,
+			Subject: SubjectEntity,
+			Name:    next.Name,
+			Detail:  fmt.Sprintf("timestamps from %v to %v", old.Timestamps, next.Timestamps),
+			API:     api,
+			Storage: persistedImpact(next, storage),
+		})
+	}
+
+	c.compareFields(old, next)
+	c.compareActions(old, next)
+	c.compareIndexes(old, next)
+}
@@ -0,0 +1,159 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+)
+
+const baseDefinition = `
+version: 1.0.0
+app:
+  name: shop
+  entities:
+    - name: customer
+      fields:
+        - { name: name, type: string, validations: [{ name: required, value: true }] }
+        - { name: email, type: string }
+      actions:
+        - { type: create }
+        - { type: getAll, authenticated: true }
+      indexes:
+        - fields: [{ name: email }]
+      persisted: true
+    - name: order
+      fields:
+        - { name: total, type: float64 }
+      actions:
+        - { type: create, output: { entity: orderInfo } }
+      persisted: true
+    - name: orderInfo
+      fields:
+        - { name: total, type: float64 }
+  relationships:
+    - { item1: customer, item2: order, type: hasMany }
+`
+
+type compareTestCase struct {
+	Description      string
+	Replacements     []string
+	ExpectedChanges  []string
+	ExpectedBreaking bool
+}
+
+func readDefinition(t *testing.T, data string) *entities.Definitions {
+	var definitions entities.Definitions
+
+	if _, err := reader.NewReader().Read([]byte(data), &definitions); err != nil {
+		t.Fatalf("reading definition: %s", err)
+	}
+
+	return &definitions
+}
+
+func TestCompare(t *testing.T) {
+	testCases := []*compareTestCase{
+		{
+			Description:     "same definitions",
+			ExpectedChanges: []string{},
+		},
+		{
+			Description:  "added optional field and action",
+			Replacements: []string{"- { name: email, type: string }", "- { name: email, type: string }\n        - { name: phone, type: string }", "- { type: create }\n", "- { type: create }\n        - { type: delete }\n"},
+			ExpectedChanges: []string{
+				"added field customer.phone (api: additive, storage: additive)",
+				"added action customer.delete (api: additive, storage: none)",
+			},
+		},
+		{
+			Description:  "added required field",
+			Replacements: []string{"- { name: email, type: string }", "- { name: email, type: string }\n        - { name: phone, type: string, validations: [{ name: required }] }"},
+			ExpectedChanges: []string{
+				"added field customer.phone (api: breaking, storage: breaking)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "renamed field",
+			Replacements: []string{"{ name: email, type: string }", "{ name: mail, type: string }", "fields: [{ name: email }]", "fields: [{ name: mail }]"},
+			ExpectedChanges: []string{
+				"renamed field customer.email to mail (api: breaking, storage: breaking)",
+				"removed index customer.email asc (api: none, storage: additive)",
+				"added index customer.mail asc (api: none, storage: additive)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "renamed entity keeps references",
+			Replacements: []string{"name: orderInfo", "name: receipt", "entity: orderInfo", "entity: receipt"},
+			ExpectedChanges: []string{
+				"renamed entity orderInfo to receipt (api: breaking, storage: none)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "removed relationship",
+			Replacements: []string{"    - { item1: customer, item2: order, type: hasMany }\n", ""},
+			ExpectedChanges: []string{
+				"removed relationship customer-order (api: breaking, storage: breaking)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "changed field type and validations",
+			Replacements: []string{"{ name: total, type: float64 }\n      actions", "{ name: total, type: int, validations: [{ name: min, value: 1 }] }\n      actions"},
+			ExpectedChanges: []string{
+				"changed field order.total: type from float64 to int (api: breaking, storage: breaking)",
+				"changed field order.total: added validations min=1 (api: breaking, storage: none)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "looser action and index",
+			Replacements: []string{"{ type: getAll, authenticated: true }", "{ type: getAll }", "fields: [{ name: email }]", "fields: [{ name: email }]\n          unique: true"},
+			ExpectedChanges: []string{
+				"changed action customer.getAll: authenticated from true to false (api: additive, storage: none)",
+				"changed index customer.email asc: unique from false to true (api: none, storage: breaking)",
+			},
+			ExpectedBreaking: true,
+		},
+		{
+			Description:  "nested relationship",
+			Replacements: []string{"type: hasMany }", "type: hasMany, nested: true }"},
+			ExpectedChanges: []string{
+				"changed relationship customer-order: nested from false to true (api: breaking, storage: breaking)",
+			},
+			ExpectedBreaking: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			next := baseDefinition
+
+			for i := 0; i+1 < len(testCase.Replacements); i += 2 {
+				if !strings.Contains(next, testCase.Replacements[i]) {
+					t.Fatalf("definition does not contain %q", testCase.Replacements[i])
+				}
+				next = strings.Replace(next, testCase.Replacements[i], testCase.Replacements[i+1], 1)
+			}
+
+			plan := Compare(readDefinition(t, baseDefinition), readDefinition(t, next))
+			changes := make([]string, 0, len(plan.Changes))
+
+			for _, change := range plan.Changes {
+				changes = append(changes, change.String())
+			}
+
+			if strings.Join(changes, "\n") != strings.Join(testCase.ExpectedChanges, "\n") {
+				t.Fatalf("expected changes:\n%s\ngot:\n%s", strings.Join(testCase.ExpectedChanges, "\n"), strings.Join(changes, "\n"))
+			}
+
+			if plan.IsBreaking() != testCase.ExpectedBreaking {
+				t.Fatalf("expected breaking to be %v", testCase.ExpectedBreaking)
+			}
+		})
+	}
+}
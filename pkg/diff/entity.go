@@ -0,0 +1,376 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+func (c *comparison) compareFields(old, next *entities.Entity) {
+	oldFields := fieldMap(old)
+	newFields := fieldMap(next)
+	removed := make([]*entities.Field, 0)
+	added := make([]*entities.Field, 0)
+	renamed := make(map[string]bool)
+
+	for _, field := range old.Fields {
+		if _, ok := newFields[field.Name]; !ok {
+			removed = append(removed, field)
+		}
+	}
+
+	for _, field := range next.Fields {
+		if _, ok := oldFields[field.Name]; !ok {
+			added = append(added, field)
+		}
+	}
+
+	// A field removed and another added with the same type and validations is a rename
+	for _, oldField := range removed {
+		match := -1
+
+		for i, newField := range added {
+			if newField != nil && fieldSignature(oldField) == fieldSignature(newField) {
+				if match >= 0 {
+					match = -1
+					break
+				}
+				match = i
+			}
+		}
+
+		if match < 0 {
+			continue
+		}
+
+		newField := added[match]
+		added[match] = nil
+		renamed[oldField.Name] = true
+
+		c.add(&Change{
+			Kind:    Renamed,
+			Subject: SubjectField,
+			Entity:  next.Name,
+			Name:    newField.Name,
+			OldName: oldField.Name,
+			API:     Breaking,
+			Storage: persistedImpact(next, Breaking),
+		})
+	}
+
+	for _, field := range removed {
+		if renamed[field.Name] {
+			continue
+		}
+
+		c.add(&Change{
+			Kind:    Removed,
+			Subject: SubjectField,
+			Entity:  next.Name,
+			Name:    field.Name,
+			API:     Breaking,
+			Storage: persistedImpact(next, Breaking),
+		})
+	}
+
+	for _, field := range added {
+		if field == nil {
+			continue
+		}
+
+		// Existing clients and stored items do not have a value for new required fields
+		impact := Additive
+		if hasValidation(field, "required") {
+			impact = Breaking
+		}
+
+		c.add(&Change{
+			Kind:    Added,
+			Subject: SubjectField,
+			Entity:  next.Name,
+			Name:    field.Name,
+			API:     impact,
+			Storage: persistedImpact(next, impact),
+		})
+	}
+
+	for _, oldField := range old.Fields {
+		if newField, ok := newFields[oldField.Name]; ok {
+			c.compareField(next, oldField, newField)
+		}
+	}
+}
+
+func (c *comparison) compareField(entity *entities.Entity, old, next *entities.Field) {
+	change := func(detail string, api, storage Impact) {
+		c.add(&Change{
+			Kind:    Changed,
+			Subject: SubjectField,
+			Entity:  entity.Name,
+			Name:    next.Name,
+			Detail:  detail,
+			API:     api,
+			Storage: persistedImpact(entity, storage),
+		})
+	}
+
+	if old.Type != next.Type {
+		change(fmt.Sprintf("type from %s to %s", old.Type, next.Type), Breaking, Breaking)
+	}
+
+	oldValidations := validationSet(old)
+	newValidations := validationSet(next)
+	addedValidations := difference(newValidations, oldValidations)
+	removedValidations := difference(oldValidations, newValidations)
+
+	// New validations may reject values clients send today
+	if len(addedValidations) > 0 {
+		change(fmt.Sprintf("added validations %s", strings.Join(addedValidations, ", ")), Breaking, None)
+	}
+
+	if len(removedValidations) > 0 {
+		change(fmt.Sprintf("removed validations %s", strings.Join(removedValidations, ", ")), Additive, None)
+	}
+
+	if old.Secret != next.Secret {
+		api := Additive
+		if next.Secret {
+			api = Breaking
+		}
+		change(fmt.Sprintf("secret from %v to %v", old.Secret, next.Secret), api, None)
+	}
+
+	// Hashed values can not be read back, and plain values are not hashed yet
+	if old.Hashed != next.Hashed {
+		change(fmt.Sprintf("hashed from %v to %v", old.Hashed, next.Hashed), None, Breaking)
+	}
+}
+
+func fieldMap(entity *entities.Entity) map[string]*entities.Field {
+	result := make(map[string]*entities.Field)
+
+	for _, field := range entity.Fields {
+		result[field.Name] = field
+	}
+
+	return result
+}
+
+func fieldSignature(field *entities.Field) string {
+	return fmt.Sprintf("%s|%s|%v|%v", field.Type, strings.Join(validationSet(field), ","), field.Secret, field.Hashed)
+}
+
+func validationSet(field *entities.Field) []string {
+	result := make([]string, 0, len(field.Validations))
+
+	for _, validation := range field.Validations {
+		if validation.Value != "" {
+			result = append(result, fmt.Sprintf("%s=%s", validation.Name, validation.Value))
+		} else {
+			result = append(result, validation.Name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func hasValidation(field *entities.Field, name string) bool {
+	for _, validation := range field.Validations {
+		if validation.Name == name && validation.Value != "false" {
+			return true
+		}
+	}
+	return false
+}
+
+// Values of a that are not in b
+func difference(a, b []string) []string {
+	result := make([]string, 0)
+
+	for _, value := range a {
+		found := false
+
+		for _, other := range b {
+			if value == other {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func (c *comparison) compareActions(old, next *entities.Entity) {
+	newActions := make(map[string]*entities.Action)
+	oldActions := make(map[string]*entities.Action)
+
+	for _, action := range next.Actions {
+		newActions[action.Type] = action
+	}
+
+	for _, action := range old.Actions {
+		oldActions[action.Type] = action
+	}
+
+	for _, action := range old.Actions {
+		newAction, ok := newActions[action.Type]
+
+		if !ok {
+			c.add(&Change{
+				Kind:    Removed,
+				Subject: SubjectAction,
+				Entity:  next.Name,
+				Name:    action.Type,
+				API:     Breaking,
+				Storage: None,
+			})
+			continue
+		}
+
+		c.compareAction(next, action, newAction)
+	}
+
+	for _, action := range next.Actions {
+		if _, ok := oldActions[action.Type]; !ok {
+			c.add(&Change{
+				Kind:    Added,
+				Subject: SubjectAction,
+				Entity:  next.Name,
+				Name:    action.Type,
+				API:     Additive,
+				Storage: None,
+			})
+		}
+	}
+}
+
+func (c *comparison) compareAction(entity *entities.Entity, old, next *entities.Action) {
+	change := func(detail string, api Impact) {
+		c.add(&Change{
+			Kind:    Changed,
+			Subject: SubjectAction,
+			Entity:  entity.Name,
+			Name:    next.Type,
+			Detail:  detail,
+			API:     api,
+			Storage: None,
+		})
+	}
+
+	if old.Authenticated != next.Authenticated {
+		api := Additive
+		if next.Authenticated {
+			api = Breaking
+		}
+		change(fmt.Sprintf("authenticated from %v to %v", old.Authenticated, next.Authenticated), api)
+	}
+
+	if c.entityName(old.Input.Entity) != next.Input.Entity {
+		change(fmt.Sprintf("input entity from %q to %q", old.Input.Entity, next.Input.Entity), Breaking)
+	}
+
+	if c.entityName(old.Output.Entity) != next.Output.Entity {
+		change(fmt.Sprintf("output entity from %q to %q", old.Output.Entity, next.Output.Entity), Breaking)
+	}
+}
+
+// entityName translates an entity name of the old definitions to the new ones
+func (c *comparison) entityName(name string) string {
+	if renamed, ok := c.renames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+func indexSignature(index *entities.Index) string {
+	fields := make([]string, 0, len(index.Fields))
+
+	for _, field := range index.Fields {
+		direction := field.Sort
+		if direction == "" {
+			direction = entities.IndexSortAsc
+		}
+		fields = append(fields, field.Name+" "+direction)
+	}
+
+	return strings.Join(fields, ", ")
+}
+
+func (c *comparison) compareIndexes(old, next *entities.Entity) {
+	oldIndexes := make(map[string]*entities.Index)
+	newIndexes := make(map[string]*entities.Index)
+
+	for _, index := range old.Indexes {
+		oldIndexes[indexSignature(index)] = index
+	}
+
+	for _, index := range next.Indexes {
+		newIndexes[indexSignature(index)] = index
+	}
+
+	for _, index := range old.Indexes {
+		signature := indexSignature(index)
+		newIndex, ok := newIndexes[signature]
+
+		if !ok {
+			c.add(&Change{
+				Kind:    Removed,
+				Subject: SubjectIndex,
+				Entity:  next.Name,
+				Name:    signature,
+				API:     None,
+				Storage: persistedImpact(next, Additive),
+			})
+			continue
+		}
+
+		if index.Unique != newIndex.Unique {
+			// Stored items may already repeat the values of a new unique index
+			storage := Additive
+			if newIndex.Unique {
+				storage = Breaking
+			}
+
+			c.add(&Change{
+				Kind:    Changed,
+				Subject: SubjectIndex,
+				Entity:  next.Name,
+				Name:    signature,
+				Detail:  fmt.Sprintf("unique from %v to %v", index.Unique, newIndex.Unique),
+				API:     None,
+				Storage: persistedImpact(next, storage),
+			})
+		}
+	}
+
+	for _, index := range next.Indexes {
+		signature := indexSignature(index)
+
+		if _, ok := oldIndexes[signature]; ok {
+			continue
+		}
+
+		storage := Additive
+		if index.Unique {
+			storage = Breaking
+		}
+
+		c.add(&Change{
+			Kind:    Added,
+			Subject: SubjectIndex,
+			Entity:  next.Name,
+			Name:    signature,
+			API:     None,
+			Storage: persistedImpact(next, storage),
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+func relationshipName(item1 string, item2 string) string {
+	return fmt.Sprintf("%s-%s", item1, item2)
+}
+
+func relationships(definitions *entities.Definitions) []*entities.Relationship {
+	if definitions == nil || definitions.App == nil {
+		return nil
+	}
+	return definitions.App.Relationships
+}
+
+func (c *comparison) compareRelationships(old, next *entities.Definitions) {
+	oldRelationships := make(map[string]*entities.Relationship)
+	newRelationships := make(map[string]*entities.Relationship)
+
+	for _, r := range relationships(old) {
+		oldRelationships[relationshipName(c.entityName(r.Item1), c.entityName(r.Item2))] = r
+	}
+
+	for _, r := range relationships(next) {
+		newRelationships[relationshipName(r.Item1, r.Item2)] = r
+	}
+
+	for _, r := range relationships(old) {
+		name := relationshipName(c.entityName(r.Item1), c.entityName(r.Item2))
+		newRelationship, ok := newRelationships[name]
+
+		if !ok {
+			c.add(&Change{
+				Kind:    Removed,
+				Subject: SubjectRelationship,
+				Name:    name,
+				API:     Breaking,
+				Storage: Breaking,
+			})
+			continue
+		}
+
+		if r.Type != newRelationship.Type {
+			c.add(&Change{
+				Kind:    Changed,
+				Subject: SubjectRelationship,
+				Name:    name,
+				Detail:  fmt.Sprintf("type from %s to %s", r.Type, newRelationship.Type),
+				API:     Breaking,
+				Storage: Breaking,
+			})
+		}
+
+		// Nested items are stored inside their owner, so the data has to move
+		if r.Nested != newRelationship.Nested {
+			c.add(&Change{
+				Kind:    Changed,
+				Subject: SubjectRelationship,
+				Name:    name,
+				Detail:  fmt.Sprintf("nested from %v to %v", r.Nested, newRelationship.Nested),
+				API:     Breaking,
+				Storage: Breaking,
+			})
+		}
+	}
+
+	for _, r := range relationships(next) {
+		name := relationshipName(r.Item1, r.Item2)
+
+		if _, ok := oldRelationships[name]; ok {
+			continue
+		}
+
+		storage := Additive
+		if r.Nested {
+			storage = Breaking
+		}
+
+		c.add(&Change{
+			Kind:    Added,
+			Subject: SubjectRelationship,
+			Name:    name,
+			API:     Additive,
+			Storage: storage,
+		})
+	}
+}
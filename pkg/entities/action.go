@@ -6,6 +6,23 @@ import (
 	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
 )
 
+const (
+	ActionTypeCreate = "create"
+	ActionTypeGetAll = "getAll"
+	ActionTypeGetOne = "getOne"
+	ActionTypeUpdate = "update"
+	ActionTypeDelete = "delete"
+)
+
+// All the action types an entity can implement
+var ActionTypes = []string{
+	ActionTypeCreate,
+	ActionTypeGetAll,
+	ActionTypeGetOne,
+	ActionTypeUpdate,
+	ActionTypeDelete,
+}
+
 type Action struct {
 	Type          string  `json:"type"`
 	Authenticated bool    `json:"authenticated"`
@@ -15,38 +32,47 @@ type Action struct {
 }
 
 func (a Action) IsCreate() bool {
-	return a.Type == "create"
+	return a.Type == ActionTypeCreate
 }
 
 func (a Action) IsGetAll() bool {
-	return a.Type == "getAll"
+	return a.Type == ActionTypeGetAll
 }
 
 func (a Action) IsGetOne() bool {
-	return a.Type == "getOne"
+	return a.Type == ActionTypeGetOne
 }
 
 func (a Action) IsUpdate() bool {
-	return a.Type == "update"
+	return a.Type == ActionTypeUpdate
 }
 
 func (a Action) IsDelete() bool {
-	return a.Type == "delete"
+	return a.Type == ActionTypeDelete
 }
 
 func (a Action) Endpoint() string {
 	return fmt.Sprintf("/v1/%s", templates.Pluralize(a.Entity.Name))
 }
 
+// Route path of the action, including the id parameter for single item actions
+func (a Action) Path() string {
+	switch a.Type {
+	case ActionTypeGetOne, ActionTypeUpdate, ActionTypeDelete:
+		return fmt.Sprintf("%s/:id", a.Endpoint())
+	}
+	return a.Endpoint()
+}
+
 func (a Action) HTTPMethod() string {
 	switch a.Type {
-	case "create":
+	case ActionTypeCreate:
 		return "POST"
-	case "getAll", "getOne":
+	case ActionTypeGetAll, ActionTypeGetOne:
 		return "GET"
-	case "update":
+	case ActionTypeUpdate:
 		return "PUT"
-	case "delete":
+	case ActionTypeDelete:
 		return "DELETE"
 	}
 	return "GET"
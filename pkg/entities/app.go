@@ -1,13 +1,24 @@
 package entities
 
 type App struct {
-	Name           string          `json:"name" validate:"min=3,max=50"`
-	Description    string          `json:"description" validate:"max=200"`
-	Version        string          `json:"version"`
-	Repository     string          `json:"repository"`
-	Type           string          `json:"type"`
-	Stack          Stack           `json:"stack" validate:"dive"`
-	Entities       []*Entity       `json:"entities" validate:"dive"`
-	Relationships  []*Relationship `json:"relationships" validate:"dive"`
-	Authentication Authentication  `json:"authentication" validate:"dive"`
+	Name           string              `json:"name" validate:"min=3,max=50"`
+	Description    string              `json:"description" validate:"max=200"`
+	Version        string              `json:"version"`
+	Repository     string              `json:"repository"`
+	Type           string              `json:"type"`
+	Stack          Stack               `json:"stack" validate:"dive"`
+	Entities       []*Entity           `json:"entities" validate:"dive"`
+	Relationships  []*Relationship     `json:"relationships" validate:"dive"`
+	Authentication Authentication      `json:"authentication" validate:"dive"`
+	FieldSets      map[string][]*Field `json:"fieldSets"` // Named groups of fields that entities can reuse
+	Mixins         map[string]*Mixin   `json:"mixins"`    // Named entity parts that entities can reuse
+}
+
+// Reusable part of an entity, e.g. "auditable" or "addressable". The reader
+// appends its fields and indexes to the entities using it.
+type Mixin struct {
+	Fields     []*Field `json:"fields"`
+	FieldSets  []string `json:"fieldSets"`
+	Timestamps bool     `json:"timestamps"`
+	Indexes    []*Index `json:"indexes"`
 }
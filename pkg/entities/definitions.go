@@ -1,9 +1,10 @@
 package entities
 
 type Definitions struct {
-	Id      string `json:"id"`
-	Version string `json:"version"`
-	App     *App   `json:"app"`
+	Id      string   `json:"id"`
+	Version string   `json:"version"`
+	Include []string `json:"include"` // Files with more entities and relationships of the app, as glob patterns
+	App     *App     `json:"app" validate:"required"`
 }
 
 func (d Definitions) HasAuthentication() bool {
@@ -1,5 +1,14 @@
 package entities
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
 // Single validation specification for a field.
 // Available validations:
 //
@@ -31,6 +40,71 @@ type Validation struct {
 	Value string `json:"value"`
 }
 
+// All the available validation names
+var ValidationNames = []string{"required", "min", "max", "email", "oneof", "len", "eq", "gt", "gte", "lt", "lte", "ne"}
+
+// Accepts numbers and booleans as the validation value, since YAML and TOML
+// definitions are usually written as "value: 3" instead of "value: \"3\""
+func (v *Validation) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	v.Name = raw.Name
+	v.Value = ""
+
+	value := bytes.TrimSpace(raw.Value)
+
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil
+	}
+
+	if value[0] == '"' {
+		return json.Unmarshal(value, &v.Value)
+	}
+
+	var scalar interface{}
+	if err := json.Unmarshal(value, &scalar); err != nil {
+		return err
+	}
+
+	switch scalar := scalar.(type) {
+	case bool:
+		v.Value = string(value)
+		return nil
+	case float64:
+		return v.setNumber(scalar, string(value))
+	}
+
+	return fmt.Errorf("validation %q: value must be a string, number or boolean", raw.Name)
+}
+
+// The validations bounding a length or a number, their values are integers
+var BoundValidations = []string{"min", "max", "len", "gt", "gte", "lt", "lte"}
+
+// Keeps a number as the validation value. Integers are written without an
+// exponent, e.g. 1e3 becomes "1000", and the bounds must be integers.
+func (v *Validation) setNumber(number float64, literal string) error {
+	if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+		v.Value = strconv.FormatInt(int64(number), 10)
+		return nil
+	}
+
+	for _, name := range BoundValidations {
+		if v.Name == name {
+			return fmt.Errorf("validation %q: value must be an integer, got %s", v.Name, literal)
+		}
+	}
+
+	v.Value = literal
+	return nil
+}
+
 // Input entity that should be used to send data to an action. E.g. some cases
 // the entity of the action should not be used to post/put information, instead another entity
 // that have some of the fields can be used.
@@ -52,8 +126,13 @@ type Authentication struct {
 
 // Defines some specifications of the implementation of the project
 type Stack struct {
-	Language string `json:"language"` // The language to be used (e.g. go, node, etc...)
-	Database string `json:"database"` // The database to be used (e.g. mongodb, mysql, etc...)
+	Language  string `json:"language"`  // The language to be used (e.g. go, node, etc...)
+	Framework string `json:"framework"` // The web framework to be used (e.g. fiber), the language default when empty
+	Database  string `json:"database"`  // The database to be used (e.g. mongodb, mysql, etc...)
+}
+
+func (s Stack) String() string {
+	return fmt.Sprintf("%s/%s/%s", s.Language, s.Framework, s.Database)
 }
 
 // Has information about a file that will be mapped in the final project. It is used by the strategy
@@ -65,8 +144,61 @@ type File struct {
 	Data         interface{}
 }
 
+// Settings given by whoever runs the engine, as opposed to the definitions file,
+// that change how a strategy generates the project
+type Options struct {
+	TemplateDirs []string // Directories searched for templates before the built-in ones
+	Seed         int64    // Seed of the example values used by the generated tests
+	Offline      bool     // Generate pinned dependency files instead of resolving the dependencies over the network
+	Steps        []string // Post build steps to run, in order. The strategy default steps, then the extra ones, when empty
+	SkipSteps    []string // Post build steps not to run
+	ExtraSteps   []*Step  // Post build steps added by the caller
+}
+
+// A named post build step, run in the directory of the generated project.
+// The step writes the output of the commands it runs to output.
+type Step struct {
+	Name string
+	Run  func(projectPath string, output io.Writer) error
+}
+
 // Build a project file map and execute commands in it in order to format, test and do some other actions
 type Strategy interface {
 	BuildFileMap() (map[string]*File, error)
 	BuildPostActions(string) error
 }
+
+// A strategy whose post build actions are a list of steps. The builder runs
+// the steps itself, so it can report the progress and the result of each one.
+type SteppedStrategy interface {
+	Strategy
+	PostSteps() ([]*Step, error) // The steps BuildPostActions would run, in order
+}
+
+// A strategy whose files depend on the last build of the project, e.g. to keep
+// the migrations the generated app already applied. Before building the file
+// map, the builder gives it a function reading the content a file had when it
+// was last generated. The function fails with an error wrapping
+// fs.ErrNotExist for the files that were not generated before.
+type IncrementalStrategy interface {
+	Strategy
+	SetPrevious(read func(name string) ([]byte, error))
+}
+
+// A strategy whose post build steps change the files of the project, e.g. to
+// format them. Before running the steps, the builder gives it the paths of the
+// files changed by the user that were not overwritten, or merged with
+// conflicts, so the steps leave them as they are.
+type ConflictAwareStrategy interface {
+	Strategy
+	SetConflicts(paths []string)
+}
+
+// A strategy reporting its files as they are rendered, so the progress of a
+// build can be followed. Before building the file map, the builder gives it a
+// function to call with every file once its Result is set. The function may
+// be called from several goroutines at the same time.
+type ProgressStrategy interface {
+	Strategy
+	SetOnRendered(rendered func(file *File))
+}
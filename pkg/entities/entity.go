@@ -10,6 +10,8 @@ type Entity struct {
 	Actions     []*Action    `json:"actions"`
 	Persisted   bool         `json:"persisted"`
 	Indexes     []*Index     `json:"indexes"`
+	FieldSets   []string     `json:"fieldSets"` // Field sets of the app appended to the fields
+	Mixins      []string     `json:"mixins"`    // Mixins of the app applied to the entity
 	Definitions *Definitions `json:"-" validate:"-"`
 }
 
@@ -130,12 +132,18 @@ func (e Entity) HasIndexes() bool {
 // Generates an map with example values for this entity.
 // The key is the field (in lowercase) and the value is
 // an example value generated within the validation constratins
-func (e Entity) Example() map[string]string {
+func (e Entity) Example() (map[string]string, error) {
 	result := make(map[string]string)
 
 	for _, field := range e.Fields {
-		result[field.Name] = field.Example()
+		example, err := field.Example()
+
+		if err != nil {
+			return nil, err
+		}
+
+		result[field.Name] = example
 	}
 
-	return result
+	return result, nil
 }
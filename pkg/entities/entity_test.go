@@ -12,9 +12,9 @@ type entityExampleTestCase struct {
 func (e *entityExampleTestCase) IsValid() bool {
 	for _, f := range e.Entity.Fields {
 		testCase := newFieldExampleTestCase(f)
-		exampleValue := f.Example()
+		exampleValue, err := f.Example()
 
-		if !testCase.IsValid(exampleValue) {
+		if err != nil || !testCase.IsValid(exampleValue) {
 			return false
 		}
 	}
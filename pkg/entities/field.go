@@ -2,12 +2,16 @@ package entities
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"strconv"
 	"strings"
 )
 
+// All the types a field can have. They are mapped to the language types by the strategy
+var FieldTypes = []string{"string", "bool", "int", "uint", "int32", "int64", "float32", "float64"}
+
 type Field struct {
 	Name        string        `json:"name"`
 	Type        string        `json:"type"`
@@ -16,7 +20,7 @@ type Field struct {
 	Hashed      bool          `json:"hashed"`
 }
 
-func randomString(chars string, size int) string {
+func randomString(r *rand.Rand, chars string, size int) string {
 	result := ""
 
 	for {
@@ -24,13 +28,26 @@ func randomString(chars string, size int) string {
 			return result
 		}
 
-		result += string(chars[rand.Intn(len(chars))])
+		result += string(chars[r.Intn(len(chars))])
 	}
 }
 
 // Generates an example value for this field.
-// The value is generated within the validation constraints
-func (f Field) Example() string {
+// The value is generated within the validation constraints and is the same on every call
+func (f Field) Example() (string, error) {
+	return f.SeededExample(0)
+}
+
+// Generates an example value for this field, within the validation constraints.
+// The value only depends on the field and the seed, so the same seed always
+// gives the same value. It fails when the value of a min, max, len, gt or lt
+// validation is not an integer.
+func (f Field) SeededExample(seed int64) (string, error) {
+	h := fnv.New64a()
+	h.Write([]byte(f.Name))
+	h.Write([]byte(f.Type))
+	r := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+
 	isNumber := false
 	var result string
 
@@ -49,7 +66,7 @@ func (f Field) Example() string {
 		if validation.Name == "max" || validation.Name == "lte" {
 			m, err := strconv.Atoi(validation.Value)
 			if err != nil {
-				panic(fmt.Sprintf("on parsing \"max\" validation: %s", err))
+				return "", fmt.Errorf("on parsing \"max\" validation of field %q: %s", f.Name, err)
 			}
 			max = m
 		}
@@ -57,7 +74,7 @@ func (f Field) Example() string {
 		if validation.Name == "lt" {
 			m, err := strconv.Atoi(validation.Value)
 			if err != nil {
-				panic(fmt.Sprintf("on parsing \"lt\" validation: %s", err))
+				return "", fmt.Errorf("on parsing \"lt\" validation of field %q: %s", f.Name, err)
 			}
 			max = m - 1
 		}
@@ -65,7 +82,7 @@ func (f Field) Example() string {
 		if validation.Name == "min" || validation.Name == "gte" {
 			m, err := strconv.Atoi(validation.Value)
 			if err != nil {
-				panic(fmt.Sprintf("on parsing \"min\" validation: %s", err))
+				return "", fmt.Errorf("on parsing \"min\" validation of field %q: %s", f.Name, err)
 			}
 			min = m
 		}
@@ -73,30 +90,30 @@ func (f Field) Example() string {
 		if validation.Name == "gt" {
 			m, err := strconv.Atoi(validation.Value)
 			if err != nil {
-				panic(fmt.Sprintf("on parsing \"gt\" validation: %s", err))
+				return "", fmt.Errorf("on parsing \"gt\" validation of field %q: %s", f.Name, err)
 			}
 			min = m + 1
 		}
 
 		if validation.Name == "eq" {
-			return validation.Value
+			return validation.Value, nil
 		}
 
 		if validation.Name == "oneof" {
-			return strings.Split(validation.Value, " ")[0]
+			return strings.Split(validation.Value, " ")[0], nil
 		}
 
 		if validation.Name == "len" {
 			m, err := strconv.Atoi(validation.Value)
 			if err != nil {
-				panic(fmt.Sprintf("on parsing \"len\" validation: %s", err))
+				return "", fmt.Errorf("on parsing \"len\" validation of field %q: %s", f.Name, err)
 			}
 			min = m
 			max = m
 		}
 
 		if validation.Name == "email" {
-			return fmt.Sprintf("example.%s@example.com", randomString("abcdefghijklmnopqrstuvwxyz1234567890", 5))
+			return fmt.Sprintf("example.%s@example.com", randomString(r, "abcdefghijklmnopqrstuvwxyz1234567890", 5)), nil
 		}
 
 		if validation.Name == "required" {
@@ -104,8 +121,6 @@ func (f Field) Example() string {
 		}
 	}
 
-	fixed := max == min
-
 	if required && min == 0 {
 		min = 1
 	}
@@ -114,21 +129,28 @@ func (f Field) Example() string {
 		max = 50
 	}
 
+	// Conflicting constraints, e.g. a min greater than the default max, give the min
+	if max < min {
+		max = min
+	}
+
 	if isNumber {
-		value := max
-		if !fixed {
-			value = rand.Intn(max-min) + min
+		value := min
+
+		if max > min {
+			value = r.Intn(max-min) + min
 		}
-		return fmt.Sprintf("%v", value)
+
+		return fmt.Sprintf("%v", value), nil
 	}
 
 	size := min
+	nRange := int(math.Ceil((float64(max-min) * 0.1)))
 
-	if !fixed {
-		nRange := int(math.Ceil((float64(max-min) * 0.1)))
-		size = rand.Intn(nRange) + min
+	if nRange > 0 {
+		size = r.Intn(nRange) + min
 	}
 
-	result = randomString("abcdefghijklmnopqrstuvwxyz", size)
-	return result
+	result = randomString(r, "abcdefghijklmnopqrstuvwxyz", size)
+	return result, nil
 }
@@ -151,6 +151,35 @@ func (i *intTestCase) Description() string {
 
 func TestFieldExample(t *testing.T) {
 	testCases := []fieldExampleTestCase{
+		&intTestCase{
+			description: "field type int required with validation tag max 1",
+			field: &Field{
+				Name: "example",
+				Type: "int",
+				Validations: []*Validation{
+					{
+						Name: "required",
+					},
+					{
+						Name:  "max",
+						Value: "1",
+					},
+				},
+			},
+		},
+		&stringTestCase{
+			description: "field type string with validation tag min greater than the default max",
+			field: &Field{
+				Name: "example",
+				Type: "string",
+				Validations: []*Validation{
+					{
+						Name:  "min",
+						Value: "60",
+					},
+				},
+			},
+		},
 		&intTestCase{
 			description: "field type int with validation tag max",
 			field: &Field{
@@ -387,10 +416,72 @@ func TestFieldExample(t *testing.T) {
 
 	for _, tCase := range testCases {
 		t.Run(tCase.Description(), func(t *testing.T) {
-			exampleValue := tCase.Field().Example()
+			exampleValue, err := tCase.Field().Example()
+			if err != nil {
+				t.Fatalf("%s: %v", tCase.Description(), err)
+			}
 			if !tCase.IsValid(exampleValue) {
 				t.Errorf("%s: example value %v is not valid", tCase.Description(), exampleValue)
 			}
 		})
 	}
 }
+
+func TestFieldSeededExample(t *testing.T) {
+	field := Field{
+		Name: "title",
+		Type: "string",
+		Validations: []*Validation{
+			{
+				Name:  "max",
+				Value: "100",
+			},
+		},
+	}
+
+	first, _ := field.Example()
+	second, _ := field.Example()
+
+	if first != second {
+		t.Errorf("Example should return the same value on every call")
+	}
+
+	first, _ = field.SeededExample(42)
+	second, _ = field.SeededExample(42)
+
+	if first != second {
+		t.Errorf("SeededExample should return the same value for the same seed")
+	}
+
+	values := make(map[string]bool)
+
+	for seed := int64(0); seed < 10; seed++ {
+		value, err := field.SeededExample(seed)
+		if err != nil {
+			t.Fatalf("SeededExample returned an error: %v", err)
+		}
+		values[value] = true
+	}
+
+	if len(values) < 2 {
+		t.Errorf("SeededExample should return different values for different seeds")
+	}
+}
+
+func TestFieldSeededExampleInvalidBound(t *testing.T) {
+	validations := []string{"min", "max", "len", "gt", "lt"}
+
+	for _, name := range validations {
+		t.Run(name, func(t *testing.T) {
+			field := Field{
+				Name:        "title",
+				Type:        "string",
+				Validations: []*Validation{{Name: name, Value: "many"}},
+			}
+
+			if _, err := field.SeededExample(0); err == nil {
+				t.Errorf("SeededExample should fail for a non integer %s validation", name)
+			}
+		})
+	}
+}
@@ -1,5 +1,13 @@
 package entities
 
+const (
+	IndexSortAsc  = "asc"
+	IndexSortDesc = "desc"
+)
+
+// All the sort directions of an index field
+var IndexSorts = []string{IndexSortAsc, IndexSortDesc}
+
 type IndexField struct {
 	Name string `json:"name"`
 	Sort string `json:"sort"`
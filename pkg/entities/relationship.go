@@ -5,6 +5,9 @@ const (
 	RelationshipTypeHasOne  = "hasOne"
 )
 
+// All the relationship types between two entities
+var RelationshipTypes = []string{RelationshipTypeHasMany, RelationshipTypeHasOne}
+
 type Relationship struct {
 	Item1      string `json:"item1" validate:"required"`
 	Item2      string `json:"item2" validate:"required"`
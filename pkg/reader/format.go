@@ -0,0 +1,174 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format of a definitions file
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+var (
+	tomlTablePattern = regexp.MustCompile(`^\[\[?[\w."-]+\]\]?\s*(#.*)?$`)
+	tomlKeyPattern   = regexp.MustCompile(`^[\w"-]+(\.[\w"-]+)*\s*=`)
+)
+
+// FormatFromPath returns the format implied by the file extension, or an empty
+// format when the extension is not known
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return ""
+}
+
+// FileFormat returns the format of a definitions file by its extension, sniffing
+// the content when the extension is not known
+func FileFormat(path string, data []byte) Format {
+	if format := FormatFromPath(path); format != "" {
+		return format
+	}
+	return DetectFormat(data)
+}
+
+// DetectFormat sniffs the content of a definitions file. JSON documents start
+// with an object, TOML documents start with a table header or a "key = value"
+// pair, and anything else is read as YAML.
+func DetectFormat(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return FormatJSON
+	}
+
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if tomlTablePattern.MatchString(line) || tomlKeyPattern.MatchString(line) {
+			return FormatTOML
+		}
+
+		break
+	}
+
+	return FormatYAML
+}
+
+// decode parses a definitions document into generic maps and slices, so every
+// format can be upgraded and then decoded by the same struct tags
+func decode(data []byte, format Format) (interface{}, error) {
+	var document interface{}
+
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&document); err != nil {
+			if syntaxErr, ok := err.(*json.SyntaxError); ok {
+				position := offsetPosition(data, int(syntaxErr.Offset))
+				return nil, fmt.Errorf("line %d, column %d: %w", position.Line, position.Column, err)
+			}
+			return nil, err
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return normalize(document), nil
+}
+
+// encode writes a document decoded by decode back in the given format
+func encode(document interface{}, format Format) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetIndent("", "    ")
+		encoder.SetEscapeHTML(false)
+
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		encoder := yaml.NewEncoder(&buffer)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		if err := toml.NewEncoder(&buffer).Encode(document); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// normalize replaces the map types produced by the YAML decoder, which may have
+// non string keys, with maps that can be encoded as JSON
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprintf("%v", key)] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	case []map[string]interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,281 @@
+package reader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Keys an included file can declare. Included files hold the entities and
+// relationships of a part of the app, and may include other files.
+var includeKeys = []string{"include", "entities", "relationships"}
+
+// includer merges the files included by a definitions document into it. The
+// included files are read from the directory of the root document, and can
+// not be outside of it, even through symbolic links.
+type includer struct {
+	fsys      fs.FS  // Directory of the root document, nil when it was not read from a file
+	dir       string // Path of that directory, used to name the included files
+	root      string // That directory with its symbolic links resolved, set on the first include
+	positions Positions
+	visited   map[string]bool
+	entities  map[string]string // Entity name to the file declaring it
+	relations map[string]string // Relationship to the file declaring it
+}
+
+// newIncluder creates an includer for a document read from dir, or for a
+// document that can not include files when dir is empty
+func newIncluder(dir string, positions Positions) *includer {
+	in := &includer{
+		dir:       dir,
+		positions: positions,
+		visited:   make(map[string]bool),
+		entities:  make(map[string]string),
+		relations: make(map[string]string),
+	}
+
+	if dir != "" {
+		in.fsys = os.DirFS(dir)
+	}
+
+	return in
+}
+
+// resolve merges the files included by the root document into its app.
+// Patterns are globs relative to the directory of the root document.
+func (in *includer) resolve(document map[string]interface{}, file string) error {
+	if _, found := document["include"]; found && in.fsys == nil {
+		return fmt.Errorf("include is only supported when reading a definitions file")
+	}
+
+	in.visited[filepath.Base(file)] = true
+	app, ok := document["app"].(map[string]interface{})
+
+	if !ok {
+		if _, found := document["include"]; !found {
+			return nil
+		}
+
+		app = make(map[string]interface{})
+		document["app"] = app
+	}
+
+	if err := in.register(app, file); err != nil {
+		return err
+	}
+
+	patterns, err := includePatterns(document["include"])
+
+	if err != nil {
+		return err
+	}
+
+	if patterns != nil {
+		document["include"] = patterns
+	}
+
+	return in.include(app, "app.", in.positions, patterns, ".")
+}
+
+// include merges the files matched by the patterns, which are relative to dir,
+// a directory of the root document directory
+func (in *includer) include(target map[string]interface{}, prefix string, targetPositions Positions, patterns []interface{}, dir string) error {
+	for _, pattern := range patterns {
+		name := path.Join(dir, filepath.ToSlash(pattern.(string)))
+
+		if filepath.IsAbs(pattern.(string)) || path.IsAbs(pattern.(string)) || !fs.ValidPath(name) {
+			return fmt.Errorf("include %q is outside the directory of the definition", pattern)
+		}
+
+		matches, err := fs.Glob(in.fsys, name)
+
+		if err != nil {
+			return fmt.Errorf("invalid include %q: %w", pattern, err)
+		}
+
+		if len(matches) == 0 {
+			return fmt.Errorf("include %q does not match any file", pattern)
+		}
+
+		for _, match := range matches {
+			if err := in.includeFile(target, prefix, targetPositions, match); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// confine fails when file, once its symbolic links are resolved, is outside
+// the directory of the root document. The patterns can not leave it, but the
+// files and directories they match may be links to anywhere.
+func (in *includer) confine(file string) error {
+	if in.root == "" {
+		root, err := filepath.EvalSymlinks(in.dir)
+
+		if err != nil {
+			return fmt.Errorf("on resolving %s: %w", in.dir, err)
+		}
+
+		in.root = root
+	}
+
+	target, err := filepath.EvalSymlinks(file)
+
+	if err != nil {
+		return fmt.Errorf("on including %s: %w", file, err)
+	}
+
+	rel, err := filepath.Rel(in.root, target)
+
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("include %s links to %s, outside the directory of the definition", file, target)
+	}
+
+	return nil
+}
+
+// includeFile merges an included file, named by its path in the root document directory
+func (in *includer) includeFile(target map[string]interface{}, prefix string, targetPositions Positions, name string) error {
+	// Files matched by more than one pattern, or including each other, are merged once
+	if in.visited[name] {
+		return nil
+	}
+
+	in.visited[name] = true
+	file := filepath.Join(in.dir, filepath.FromSlash(name))
+
+	if err := in.confine(file); err != nil {
+		return err
+	}
+
+	data, err := fs.ReadFile(in.fsys, name)
+
+	if err != nil {
+		return fmt.Errorf("on including %s: %w", file, err)
+	}
+
+	format := FileFormat(file, data)
+	decoded, err := decode(data, format)
+
+	if err != nil {
+		return fmt.Errorf("on including %s: error while parsing %s data: %w", file, format, err)
+	}
+
+	fragment, ok := decoded.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("on including %s: expected an object", file)
+	}
+
+	for key := range fragment {
+		if !contains(includeKeys, key) {
+			return fmt.Errorf("on including %s: unsupported key %q, included files can only declare %s", file, key, strings.Join(includeKeys, ", "))
+		}
+	}
+
+	fragmentPositions := positions(data, format)
+
+	for path, position := range fragmentPositions {
+		position.File = file
+		fragmentPositions[path] = position
+	}
+
+	if err := in.register(fragment, file); err != nil {
+		return err
+	}
+
+	patterns, err := includePatterns(fragment["include"])
+
+	if err != nil {
+		return fmt.Errorf("on including %s: %w", file, err)
+	}
+
+	if err := in.include(fragment, "", fragmentPositions, patterns, path.Dir(name)); err != nil {
+		return err
+	}
+
+	for _, key := range []string{"entities", "relationships"} {
+		items, _ := fragment[key].([]interface{})
+		existing, _ := target[key].([]interface{})
+
+		movePositions(fragmentPositions, key, targetPositions, prefix+key, len(existing))
+		target[key] = append(existing, items...)
+	}
+
+	return nil
+}
+
+// register records where the entities and relationships of a document are
+// declared, failing when another file already declared them
+func (in *includer) register(document map[string]interface{}, file string) error {
+	entities, _ := document["entities"].([]interface{})
+
+	for _, item := range entities {
+		entity, _ := item.(map[string]interface{})
+		name := fmt.Sprintf("%v", entity["name"])
+
+		if previous, ok := in.entities[name]; ok && previous != file {
+			return fmt.Errorf("entity %q is declared in both %s and %s", name, previous, file)
+		}
+
+		in.entities[name] = file
+	}
+
+	relationships, _ := document["relationships"].([]interface{})
+
+	for _, item := range relationships {
+		relationship, _ := item.(map[string]interface{})
+		name := fmt.Sprintf("%v-%v", relationship["item1"], relationship["item2"])
+
+		if previous, ok := in.relations[name]; ok && previous != file {
+			return fmt.Errorf("relationship %s is declared in both %s and %s", name, previous, file)
+		}
+
+		in.relations[name] = file
+	}
+
+	return nil
+}
+
+// includePatterns accepts a single pattern or a list of patterns
+func includePatterns(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []interface{}{v}, nil
+	case []interface{}:
+		for _, pattern := range v {
+			if _, ok := pattern.(string); !ok {
+				return nil, fmt.Errorf("include must be a list of file patterns")
+			}
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("include must be a list of file patterns")
+}
+
+// movePositions copies the positions of the items of a list, e.g. "entities[1].name",
+// to their place in the list they were appended to, e.g. "app.entities[4].name"
+func movePositions(from Positions, key string, to Positions, target string, offset int) {
+	for path, position := range from {
+		if !strings.HasPrefix(path, key+"[") {
+			continue
+		}
+
+		end := strings.Index(path, "]")
+		index, err := strconv.Atoi(path[len(key)+1 : end])
+
+		if err != nil {
+			continue
+		}
+
+		to[fmt.Sprintf("%s[%d]%s", target, index+offset, path[end+1:])] = position
+	}
+}
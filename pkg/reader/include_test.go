@@ -0,0 +1,270 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type includeTestCase struct {
+	Description      string
+	Files            map[string]string
+	ExpectedEntities []string
+	ExpectedError    string
+}
+
+const includeRoot = `
+version: 1.0.0
+include:
+  - entities/*.yaml
+app:
+  name: shop
+  entities:
+    - name: customer
+      fields: [{ name: name, type: string }]
+      actions: [{ type: create }]
+      persisted: true
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("creating directory: %s", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("writing file: %s", err)
+		}
+	}
+
+	return dir
+}
+
+func TestReadFileIncludes(t *testing.T) {
+	testCases := []*includeTestCase{
+		{
+			Description: "entities from a directory glob",
+			Files: map[string]string{
+				"app.yaml":              includeRoot,
+				"entities/order.yaml":   "entities:\n  - { name: order, fields: [{ name: total, type: float64 }], actions: [{ type: create }], persisted: true }\nrelationships:\n  - { item1: customer, item2: order, type: hasMany }\n",
+				"entities/product.json": `{"entities": [{"name": "ignored"}]}`,
+				"entities/invoice.yaml": "include: ../shared/*.toml\nentities:\n  - { name: invoice }\n",
+				"shared/tax.toml":       "[[entities]]\nname = \"tax\"\n",
+			},
+			ExpectedEntities: []string{"customer", "invoice", "tax", "order"},
+		},
+		{
+			Description: "files including each other",
+			Files: map[string]string{
+				"app.yaml":          includeRoot,
+				"entities/a.yaml":   "include: b.yaml\nentities: [{ name: a }]\n",
+				"entities/b.yaml":   "include: a.yaml\nentities: [{ name: b }]\n",
+				"entities/c.yaml":   "include: ../app.yaml\nentities: [{ name: c }]\n",
+				"entities/c.txt":    "not included",
+				"entities/d/e.yaml": "entities: [{ name: e }]",
+			},
+			ExpectedEntities: []string{"customer", "a", "b", "c"},
+		},
+		{
+			Description: "entity declared twice",
+			Files: map[string]string{
+				"app.yaml":             includeRoot,
+				"entities/client.yaml": "entities: [{ name: customer }]\n",
+			},
+			ExpectedError: "entity \"customer\" is declared in both",
+		},
+		{
+			Description: "relationship declared twice",
+			Files: map[string]string{
+				"app.yaml":        includeRoot,
+				"entities/a.yaml": "relationships: [{ item1: customer, item2: a, type: hasOne }]\n",
+				"entities/b.yaml": "relationships: [{ item1: customer, item2: a, type: hasMany }]\n",
+			},
+			ExpectedError: "relationship customer-a is declared in both",
+		},
+		{
+			Description: "pattern without files",
+			Files: map[string]string{
+				"app.yaml": includeRoot,
+			},
+			ExpectedError: "include \"entities/*.yaml\" does not match any file",
+		},
+		{
+			Description: "unsupported key in included file",
+			Files: map[string]string{
+				"app.yaml":        includeRoot,
+				"entities/a.yaml": "app: { name: other }\n",
+			},
+			ExpectedError: "unsupported key \"app\"",
+		},
+		{
+			Description: "absolute pattern",
+			Files: map[string]string{
+				"app.yaml": strings.Replace(includeRoot, "entities/*.yaml", "/etc/*", 1),
+			},
+			ExpectedError: "include \"/etc/*\" is outside the directory of the definition",
+		},
+		{
+			Description: "pattern outside of the definition directory",
+			Files: map[string]string{
+				"app.yaml":        includeRoot,
+				"entities/a.yaml": "include: ../../secrets/*.json\nentities: [{ name: a }]\n",
+			},
+			ExpectedError: "include \"../../secrets/*.json\" is outside the directory of the definition",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			dir := writeFiles(t, testCase.Files)
+
+			var definitions entities.Definitions
+			_, err := NewReader().ReadFile(filepath.Join(dir, "app.yaml"), &definitions)
+
+			if testCase.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.ExpectedError) {
+					t.Fatalf("expected error %q, got %v", testCase.ExpectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := make([]string, 0)
+
+			for _, entity := range definitions.App.Entities {
+				names = append(names, entity.Name)
+
+				if entity.Definitions != &definitions {
+					t.Fatalf("entity %s is not linked to its definitions", entity.Name)
+				}
+			}
+
+			if strings.Join(names, " ") != strings.Join(testCase.ExpectedEntities, " ") {
+				t.Fatalf("expected entities %v, got %v", testCase.ExpectedEntities, names)
+			}
+		})
+	}
+}
+
+func TestValidateIncludedPositions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yaml":            includeRoot,
+		"entities/order.yaml": "entities:\n  - name: order\n    fields: [{ name: total, type: float64 }]\n    actions: [{ type: create }]\n    persisted: true\nrelationships:\n  - { item1: customer, item2: ordr, type: hasMany }\n",
+	})
+
+	var definitions entities.Definitions
+	r := NewReader()
+
+	positions, err := r.ReadFile(filepath.Join(dir, "app.yaml"), &definitions)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result := r.Validate(&definitions, positions)
+
+	if result == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	fieldErr := result.Errors[0]
+
+	if fieldErr.Path != "app.relationships[0].item2" || fieldErr.Line != 7 || fieldErr.Column != 31 {
+		t.Fatalf("unexpected error position %s %d:%d", fieldErr.Path, fieldErr.Line, fieldErr.Column)
+	}
+
+	if fieldErr.File != filepath.Join(dir, "entities/order.yaml") {
+		t.Fatalf("unexpected error file %s", fieldErr.File)
+	}
+
+	if fieldErr.Suggestion != "order" {
+		t.Fatalf("expected suggestion order, got %q", fieldErr.Suggestion)
+	}
+}
+
+func TestReadRejectsIncludes(t *testing.T) {
+	var definitions entities.Definitions
+	_, err := NewReader().Read([]byte(includeRoot), &definitions)
+
+	if err == nil || !strings.Contains(err.Error(), "include is only supported when reading a definitions file") {
+		t.Fatalf("expected include to be rejected, got %v", err)
+	}
+}
+
+func TestReadFileIncludeSymlinks(t *testing.T) {
+	testCases := []struct {
+		Description   string
+		Link          string // Path of the link in the definition directory
+		Target        string // Path of its target, relative to the parent of the definition directory
+		ExpectedError string
+	}{
+		{
+			Description: "link to a file inside the directory",
+			Link:        "entities/order.yaml",
+			Target:      "app/shared/order.yaml",
+		},
+		{
+			Description:   "link to a file outside the directory",
+			Link:          "entities/order.yaml",
+			Target:        "secrets/order.yaml",
+			ExpectedError: "outside the directory of the definition",
+		},
+		{
+			Description:   "link to a directory outside the directory",
+			Link:          "entities",
+			Target:        "secrets",
+			ExpectedError: "outside the directory of the definition",
+		},
+	}
+
+	order := "entities: [{ name: order }]\n"
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			parent := writeFiles(t, map[string]string{
+				"app/app.yaml":          includeRoot,
+				"app/shared/order.yaml": order,
+				"secrets/order.yaml":    order,
+			})
+
+			dir := filepath.Join(parent, "app")
+			link := filepath.Join(dir, testCase.Link)
+
+			if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+				t.Fatalf("creating directory: %s", err)
+			}
+
+			if err := os.Symlink(filepath.Join(parent, testCase.Target), link); err != nil {
+				t.Skipf("symbolic links are not supported: %s", err)
+			}
+
+			var definitions entities.Definitions
+			_, err := NewReader().ReadFile(filepath.Join(dir, "app.yaml"), &definitions)
+
+			if testCase.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.ExpectedError) {
+					t.Fatalf("expected error %q, got %v", testCase.ExpectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(definitions.App.Entities) != 2 {
+				t.Fatalf("expected the linked entity to be included, got %d entities", len(definitions.App.Entities))
+			}
+		})
+	}
+}
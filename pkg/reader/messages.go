@@ -0,0 +1,107 @@
+package reader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// validatorMessage describes a failed struct tag validation in plain English
+func validatorMessage(tag string, param string, kind reflect.Kind) string {
+	unit := ""
+
+	switch kind {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch tag {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must have at least %s%s", param, unit)
+	case "max", "lte":
+		return fmt.Sprintf("must have at most %s%s", param, unit)
+	case "len":
+		return fmt.Sprintf("must have exactly %s%s", param, unit)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(param), ", "))
+	case "email":
+		return "must be a valid email address"
+	}
+
+	if param != "" {
+		return fmt.Sprintf("does not pass the %q validation", tag+"="+param)
+	}
+
+	return fmt.Sprintf("does not pass the %q validation", tag)
+}
+
+// suggest returns the candidate closest to a misspelled value, or an empty
+// string when none of them is close enough to be what the user meant
+func suggest(value string, candidates []string) string {
+	best := ""
+	bestDistance := len(value)/3 + 1
+
+	for _, candidate := range candidates {
+		if candidate == value {
+			continue
+		}
+
+		distance := editDistance(strings.ToLower(value), strings.ToLower(candidate))
+
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+// editDistance returns the number of single character edits (insertions,
+// deletions, substitutions and transpositions of adjacent characters) to turn a into b
+func editDistance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+	distances := make([][]int, len(ar)+1)
+
+	for i := range distances {
+		distances[i] = make([]int, len(br)+1)
+		distances[i][0] = i
+	}
+
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			distances[i][j] = minInt(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				distances[i][j] = minInt(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return distances[len(ar)][len(br)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,138 @@
+package reader
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// expandMixins appends the fields of the field sets and the fields and indexes
+// of the mixins used by each entity after its own ones. Unknown names are
+// skipped here and reported by Validate.
+func expandMixins(app *entities.App) {
+	for _, entity := range app.Entities {
+		for _, name := range entity.FieldSets {
+			entity.Fields = append(entity.Fields, copyFields(app.FieldSets[name])...)
+		}
+
+		for _, name := range entity.Mixins {
+			mixin, ok := app.Mixins[name]
+
+			if !ok || mixin == nil {
+				continue
+			}
+
+			entity.Fields = append(entity.Fields, copyFields(mixin.Fields)...)
+
+			for _, fieldSet := range mixin.FieldSets {
+				entity.Fields = append(entity.Fields, copyFields(app.FieldSets[fieldSet])...)
+			}
+
+			for _, index := range mixin.Indexes {
+				entity.Indexes = append(entity.Indexes, copyIndex(index))
+			}
+
+			entity.Timestamps = entity.Timestamps || mixin.Timestamps
+		}
+	}
+}
+
+// Entities get their own copies, so templates can never change a shared field
+func copyFields(fields []*entities.Field) []*entities.Field {
+	result := make([]*entities.Field, 0, len(fields))
+
+	for _, field := range fields {
+		if field == nil {
+			continue
+		}
+
+		copied := *field
+		copied.Validations = make([]*entities.Validation, 0, len(field.Validations))
+
+		for _, validation := range field.Validations {
+			v := *validation
+			copied.Validations = append(copied.Validations, &v)
+		}
+
+		result = append(result, &copied)
+	}
+
+	return result
+}
+
+func copyIndex(index *entities.Index) *entities.Index {
+	copied := *index
+	copied.Fields = make([]*entities.IndexField, 0, len(index.Fields))
+
+	for _, field := range index.Fields {
+		f := *field
+		copied.Fields = append(copied.Fields, &f)
+	}
+
+	return &copied
+}
+
+// validateMixins checks the field sets and mixins referenced by the app and its entities
+func validateMixins(app *entities.App) []*FieldError {
+	errors := make([]*FieldError, 0)
+	fieldSets := make([]string, 0, len(app.FieldSets))
+	mixins := make([]string, 0, len(app.Mixins))
+
+	for name := range app.FieldSets {
+		fieldSets = append(fieldSets, name)
+	}
+
+	for name := range app.Mixins {
+		mixins = append(mixins, name)
+	}
+
+	sort.Strings(fieldSets)
+	sort.Strings(mixins)
+
+	for _, name := range mixins {
+		if app.Mixins[name] == nil {
+			continue
+		}
+
+		for index, fieldSet := range app.Mixins[name].FieldSets {
+			if !contains(fieldSets, fieldSet) {
+				errors = append(errors, unknownFieldSet(fmt.Sprintf("app.mixins.%s.fieldSets[%v]", name, index), fieldSet, fieldSets))
+			}
+		}
+	}
+
+	for entityIndex, entity := range app.Entities {
+		path := fmt.Sprintf("app.entities[%v]", entityIndex)
+
+		for index, fieldSet := range entity.FieldSets {
+			if !contains(fieldSets, fieldSet) {
+				errors = append(errors, unknownFieldSet(fmt.Sprintf("%s.fieldSets[%v]", path, index), fieldSet, fieldSets))
+			}
+		}
+
+		for index, mixin := range entity.Mixins {
+			if !contains(mixins, mixin) {
+				errors = append(errors, &FieldError{
+					Field:      fmt.Sprintf("%s.mixins[%v]", path, index),
+					Tag:        "mixin",
+					Value:      mixin,
+					Message:    fmt.Sprintf("unknown mixin %q", mixin),
+					Suggestion: suggest(mixin, mixins),
+				})
+			}
+		}
+	}
+
+	return errors
+}
+
+func unknownFieldSet(path string, name string, names []string) *FieldError {
+	return &FieldError{
+		Field:      path,
+		Tag:        "fieldSet",
+		Value:      name,
+		Message:    fmt.Sprintf("unknown field set %q", name),
+		Suggestion: suggest(name, names),
+	}
+}
@@ -0,0 +1,127 @@
+package reader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+const mixinsDefinition = `
+version: 1.0.0
+app:
+  name: shop
+  fieldSets:
+    credentials:
+      - { name: email, type: string, validations: [{ name: email }] }
+      - { name: password, type: string, secret: true, hashed: true }
+    address:
+      - { name: street, type: string }
+      - { name: city, type: string }
+  mixins:
+    auditable:
+      fields:
+        - { name: createdBy, type: string }
+      timestamps: true
+      indexes:
+        - fields: [{ name: createdBy }]
+    addressable:
+      fieldSets: [address]
+  entities:
+    - name: user
+      fields:
+        - { name: name, type: string }
+      fieldSets: [credentials]
+      mixins: [auditable, addressable]
+      actions: [{ type: create }]
+      persisted: true
+    - name: store
+      fields:
+        - { name: name, type: string }
+      mixins: [addressable]
+      actions: [{ type: create }]
+      persisted: true
+  authentication:
+    entity: user
+`
+
+func TestReadExpandsMixins(t *testing.T) {
+	var definitions entities.Definitions
+	r := NewReader()
+
+	positions, err := r.Read([]byte(mixinsDefinition), &definitions)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if validationErr := r.Validate(&definitions, positions); validationErr != nil {
+		errors, _ := json.Marshal(validationErr.Errors)
+		t.Fatalf("unexpected validation errors: %s", errors)
+	}
+
+	user := definitions.FindEntity("user")
+	names := make([]string, 0)
+
+	for _, field := range user.Fields {
+		names = append(names, field.Name)
+	}
+
+	expected := "name email password createdBy street city"
+
+	if strings.Join(names, " ") != expected {
+		t.Fatalf("expected fields %s, got %s", expected, strings.Join(names, " "))
+	}
+
+	if !user.Timestamps || len(user.Indexes) != 1 || !user.Fields[2].Hashed {
+		t.Fatalf("mixin was not applied: timestamps %v, indexes %d", user.Timestamps, len(user.Indexes))
+	}
+
+	// Each entity gets its own copy of the shared fields
+	store := definitions.FindEntity("store")
+	store.Fields[1].Name = "road"
+
+	if user.Fields[4].Name != "street" {
+		t.Fatal("entities share the fields of a field set")
+	}
+}
+
+func TestValidateMixins(t *testing.T) {
+	data := strings.Replace(mixinsDefinition, "mixins: [auditable, addressable]", "mixins: [auditible, addressable]", 1)
+	data = strings.Replace(data, "fieldSets: [address]", "fieldSets: [adress]", 1)
+
+	var definitions entities.Definitions
+	r := NewReader()
+
+	positions, err := r.Read([]byte(data), &definitions)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result := r.Validate(&definitions, positions)
+
+	if result == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	messages := make([]string, 0)
+
+	for _, fieldErr := range result.Errors {
+		messages = append(messages, fieldErr.Path+": "+fieldErr.Message+", "+fieldErr.Suggestion)
+	}
+
+	expected := []string{
+		"app.mixins.addressable.fieldSets[0]: unknown field set \"adress\", address",
+		"app.entities[0].mixins[0]: unknown mixin \"auditible\", auditable",
+	}
+
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected errors:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+
+	if result.Errors[1].Line != 26 {
+		t.Fatalf("expected the mixin error on line 26, got %d", result.Errors[1].Line)
+	}
+}
@@ -0,0 +1,175 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position of a value in the original definitions file. Lines and columns
+// start at 1, a zero line means the position is unknown.
+type Position struct {
+	File   string `json:"file,omitempty"` // Included file declaring the value, empty for the read document
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Positions of the values of a definitions file, keyed by their path, e.g.
+// "app.entities[2].name". The root value has an empty path.
+type Positions map[string]Position
+
+// Lookup returns the position of the value at path or, when the value is not
+// in the file (e.g. a missing required key), of its closest parent
+func (p Positions) Lookup(path string) Position {
+	for {
+		if position, ok := p[path]; ok {
+			return position
+		}
+
+		if path == "" {
+			return Position{}
+		}
+
+		path = parentPath(path)
+	}
+}
+
+func parentPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+
+	if i < 0 {
+		return ""
+	}
+
+	return path[:i]
+}
+
+func childPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// positions collects the positions of a definitions document. Formats without
+// position information, like TOML, return an empty table.
+func positions(data []byte, format Format) Positions {
+	switch format {
+	case FormatJSON:
+		return jsonPositions(data)
+	case FormatYAML:
+		return yamlPositions(data)
+	}
+	return Positions{}
+}
+
+func jsonPositions(data []byte) Positions {
+	result := make(Positions)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		// The decoder offset is at the end of the previous token, so the
+		// separators before the value are skipped
+		result[path] = offsetPosition(data, skipSeparators(data, int(decoder.InputOffset())))
+
+		token, err := decoder.Token()
+
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+
+				if err != nil {
+					return err
+				}
+
+				if err := walk(childPath(path, fmt.Sprintf("%v", key))); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+
+		return err
+	}
+
+	// Malformed documents fail on unmarshaling, the positions found so far are kept
+	_ = walk("")
+
+	return result
+}
+
+func skipSeparators(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func offsetPosition(data []byte, offset int) Position {
+	if offset > len(data) {
+		offset = len(data)
+	}
+
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+
+	return Position{Line: line, Column: column}
+}
+
+func yamlPositions(data []byte) Positions {
+	result := make(Positions)
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return result
+	}
+
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+
+		result[path] = Position{Line: node.Line, Column: node.Column}
+
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) > 0 {
+				walk(node.Content[0], path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], childPath(path, node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+
+	walk(&document, "")
+
+	return result
+}
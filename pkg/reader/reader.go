@@ -3,6 +3,12 @@ package reader
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
 	"github.com/go-playground/validator/v10"
@@ -13,9 +19,15 @@ const (
 )
 
 type FieldError struct {
-	Field string `json:"field"`
-	Tag   string `json:"tag"`
-	Value string `json:"value"`
+	Field      string `json:"field"`
+	Tag        string `json:"tag"`
+	Value      string `json:"value"`
+	Path       string `json:"path"`                 // Path of the value in the file, e.g. app.entities[2].name
+	File       string `json:"file,omitempty"`       // Included file declaring the value, empty for the read document
+	Line       int    `json:"line,omitempty"`       // Line of the value in its file, when known
+	Column     int    `json:"column,omitempty"`     // Column of the value in its file, when known
+	Message    string `json:"message"`              // Plain English description of the error
+	Suggestion string `json:"suggestion,omitempty"` // Closest valid name, for misspelled names
 }
 
 type ValidationError struct {
@@ -27,20 +39,72 @@ func (*ValidationError) Error() string {
 	return ErrorMessage
 }
 
+// Reader decodes definitions documents. The read methods return the positions
+// of the values in the document, which Validate uses to locate the errors.
 type Reader interface {
-	Read([]byte, *entities.Definitions) error
-	Validate(*entities.Definitions) *ValidationError
+	// Read parses a definitions document, sniffing whether it is JSON, YAML or TOML.
+	// The document can not include other files.
+	Read([]byte, *entities.Definitions) (Positions, error)
+	// ReadFormat parses a definitions document in the given format. The
+	// document can not include other files.
+	ReadFormat([]byte, Format, *entities.Definitions) (Positions, error)
+	// ReadFile parses a definitions file, choosing the format by its extension.
+	// Included files are relative to the directory of the file, and must be in it.
+	ReadFile(string, *entities.Definitions) (Positions, error)
+	// Validate checks the definitions. The errors carry the line and column of
+	// the value found in the positions, which may be nil when unknown.
+	Validate(*entities.Definitions, Positions) *ValidationError
 }
 
 type reader struct{}
 
-func (r *reader) Read(data []byte, output *entities.Definitions) error {
-	err := json.Unmarshal(data, output)
+func (r *reader) Read(data []byte, output *entities.Definitions) (Positions, error) {
+	return r.ReadFormat(data, DetectFormat(data), output)
+}
+
+func (r *reader) ReadFormat(data []byte, format Format, output *entities.Definitions) (Positions, error) {
+	return r.read(data, format, "", "definition", output)
+}
+
+// read parses a definitions document whose includes are relative to dir, or
+// that can not include files when dir is empty. The file name is only used to
+// report conflicts with the included files.
+func (r *reader) read(data []byte, format Format, dir string, file string, output *entities.Definitions) (Positions, error) {
+	documentPositions := positions(data, format)
+	document, err := decode(data, format)
+
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing %s data: %w", format, err)
+	}
+
+	if object, ok := document.(map[string]interface{}); ok {
+		if err := newIncluder(dir, documentPositions).resolve(object, file); err != nil {
+			return nil, fmt.Errorf("error while including files: %w", err)
+		}
+
+		if _, err := upgradeDocument(object); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = json.Marshal(document)
 
 	if err != nil {
-		return fmt.Errorf("error while unmarshaling data: %w", err)
+		return nil, fmt.Errorf("error while parsing %s data: %w", format, err)
+	}
+
+	err = json.Unmarshal(data, output)
+
+	if err != nil {
+		return nil, fmt.Errorf("error while unmarshaling data: %w", err)
+	}
+
+	if output.App == nil {
+		return documentPositions, nil
 	}
 
+	expandMixins(output.App)
+
 	for _, entity := range output.App.Entities {
 		entity.Definitions = output
 
@@ -49,13 +113,26 @@ func (r *reader) Read(data []byte, output *entities.Definitions) error {
 		}
 	}
 
-	return nil
+	return documentPositions, nil
+}
+
+func (r *reader) ReadFile(path string, output *entities.Definitions) (Positions, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("error while reading file: %w", err)
+	}
+
+	return r.read(data, FileFormat(path, data), filepath.Dir(path), path, output)
 }
 
-func (r *reader) Validate(definitions *entities.Definitions) *ValidationError {
+func (r *reader) Validate(definitions *entities.Definitions, positions Positions) *ValidationError {
 	errors := make([]*FieldError, 0)
 
 	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
 	err := validate.Struct(definitions)
 
 	if err != nil {
@@ -64,38 +141,122 @@ func (r *reader) Validate(definitions *entities.Definitions) *ValidationError {
 			element.Field = err.StructNamespace()
 			element.Tag = err.Tag()
 			element.Value = err.Param()
+			element.Path = strings.TrimPrefix(err.Namespace(), "Definitions.")
+			element.Message = validatorMessage(err.Tag(), err.Param(), err.Kind())
 
 			errors = append(errors, &element)
 		}
 
 		if len(errors) > 0 {
-			return &ValidationError{
+			return locate(&ValidationError{
 				Message: ErrorMessage,
 				Errors:  errors,
-			}
+			}, positions)
 		}
 	}
 
 	customValidationError := customValidation(definitions)
 
 	if customValidationError != nil {
-		return customValidationError
+		return locate(customValidationError, positions)
 	}
 
 	return nil
 }
 
+// locate fills the line and column of the errors from the positions of the read document
+func locate(validationErr *ValidationError, positions Positions) *ValidationError {
+	for _, fieldErr := range validationErr.Errors {
+		if fieldErr.Path == "" {
+			fieldErr.Path = fieldErr.Field
+		}
+
+		position := positions.Lookup(fieldErr.Path)
+		fieldErr.File = position.File
+		fieldErr.Line = position.Line
+		fieldErr.Column = position.Column
+	}
+
+	return validationErr
+}
+
+// Implicit fields every entity has, besides the ones declared in the definitions
+var implicitFields = []string{"id", "createdAt", "updatedAt"}
+
+// customValidation checks the references between the parts of the definitions,
+// which the struct tags can not express. Templates assume these references are
+// valid, so an error here would otherwise become a nil pointer while rendering.
 func customValidation(definitions *entities.Definitions) *ValidationError {
 	errors := make([]*FieldError, 0)
+	entityNames := make(map[string]bool)
+	names := make([]string, 0, len(definitions.App.Entities))
 
-	// Validate entities
+	for _, entity := range definitions.App.Entities {
+		names = append(names, entity.Name)
+	}
+
+	// Validate the references to entities first, since entity methods such as
+	// IsNested and BelongsTo look the related entities up
 	for index, entity := range definitions.App.Entities {
-		if !entity.IsNested() && entity.Persisted && len(entity.Actions) == 0 {
+		path := fmt.Sprintf("app.entities[%v]", index)
+
+		if entityNames[entity.Name] {
 			errors = append(errors, &FieldError{
-				Field: fmt.Sprintf("app.entities[%v].actions", index),
-				Tag:   "not empty",
+				Field:   path + ".name",
+				Tag:     "unique",
+				Value:   entity.Name,
+				Message: fmt.Sprintf("entity %q is declared more than once", entity.Name),
 			})
 		}
+
+		entityNames[entity.Name] = true
+	}
+
+	for index, relationship := range definitions.App.Relationships {
+		path := fmt.Sprintf("app.relationships[%v]", index)
+
+		if !entityNames[relationship.Item1] {
+			errors = append(errors, unknownEntity(path+".item1", relationship.Item1, names))
+		}
+
+		if !entityNames[relationship.Item2] {
+			errors = append(errors, unknownEntity(path+".item2", relationship.Item2, names))
+		}
+	}
+
+	authEntity := definitions.App.Authentication.Entity
+
+	if authEntity != "" && !entityNames[authEntity] {
+		errors = append(errors, unknownEntity("app.authentication.entity", authEntity, names))
+	}
+
+	errors = append(errors, validateMixins(definitions.App)...)
+
+	if len(errors) > 0 {
+		return &ValidationError{
+			Message: "validation error",
+			Errors:  errors,
+		}
+	}
+
+	// Validate entities
+	for index, entity := range definitions.App.Entities {
+		errors = append(errors, validateEntity(definitions, entity, fmt.Sprintf("app.entities[%v]", index), names)...)
+	}
+
+	if authEntity != "" {
+		entity := definitions.FindEntity(authEntity)
+
+		for _, field := range []string{"email", "password"} {
+			if findField(entity, field) == nil {
+				errors = append(errors, &FieldError{
+					Field:   "app.authentication.entity",
+					Tag:     "has field",
+					Value:   field,
+					Message: fmt.Sprintf("authentication entity %q must have a %q field", authEntity, field),
+				})
+			}
+		}
 	}
 
 	if len(errors) > 0 {
@@ -108,6 +269,201 @@ func customValidation(definitions *entities.Definitions) *ValidationError {
 	return nil
 }
 
+func validateEntity(definitions *entities.Definitions, entity *entities.Entity, path string, names []string) []*FieldError {
+	errors := make([]*FieldError, 0)
+
+	if !entity.IsNested() && entity.Persisted && len(entity.Actions) == 0 {
+		errors = append(errors, &FieldError{
+			Field:   path + ".actions",
+			Tag:     "not empty",
+			Message: fmt.Sprintf("persisted entity %q must have at least one action", entity.Name),
+		})
+	}
+
+	fieldNames := make(map[string]bool)
+
+	for index, field := range entity.Fields {
+		fieldPath := fmt.Sprintf("%s.fields[%v]", path, index)
+
+		if fieldNames[field.Name] {
+			errors = append(errors, &FieldError{
+				Field:   fieldPath + ".name",
+				Tag:     "unique",
+				Value:   field.Name,
+				Message: fmt.Sprintf("field %q is declared more than once in entity %q", field.Name, entity.Name),
+			})
+		}
+
+		fieldNames[field.Name] = true
+
+		if !contains(entities.FieldTypes, field.Type) {
+			errors = append(errors, &FieldError{
+				Field:      fieldPath + ".type",
+				Tag:        "oneof",
+				Value:      strings.Join(entities.FieldTypes, " "),
+				Message:    fmt.Sprintf("unknown field type %q, must be one of: %s", field.Type, strings.Join(entities.FieldTypes, ", ")),
+				Suggestion: suggest(field.Type, entities.FieldTypes),
+			})
+		}
+
+		errors = append(errors, validateBounds(field, fieldPath)...)
+	}
+
+	for index, action := range entity.Actions {
+		actionPath := fmt.Sprintf("%s.actions[%v]", path, index)
+
+		if !contains(entities.ActionTypes, action.Type) {
+			errors = append(errors, &FieldError{
+				Field:      actionPath + ".type",
+				Tag:        "oneof",
+				Value:      strings.Join(entities.ActionTypes, " "),
+				Message:    fmt.Sprintf("unknown action type %q, must be one of: %s", action.Type, strings.Join(entities.ActionTypes, ", ")),
+				Suggestion: suggest(action.Type, entities.ActionTypes),
+			})
+		}
+
+		if name := action.Input.Entity; name != "" && definitions.FindEntity(name) == nil {
+			errors = append(errors, unknownEntity(actionPath+".input.entity", name, names))
+		}
+
+		if name := action.Output.Entity; name != "" {
+			output := definitions.FindEntity(name)
+
+			if output == nil {
+				errors = append(errors, unknownEntity(actionPath+".output.entity", name, names))
+				continue
+			}
+
+			for _, field := range output.Fields {
+				if findField(entity, field.Name) == nil && !contains(implicitFields, field.Name) {
+					errors = append(errors, &FieldError{
+						Field:   actionPath + ".output.entity",
+						Tag:     "subset",
+						Value:   entity.Name,
+						Message: fmt.Sprintf("output entity %q has the field %q, which entity %q does not have", name, field.Name, entity.Name),
+					})
+					break
+				}
+			}
+		}
+	}
+
+	// Foreign keys of the owner entities can be indexed as well
+	indexable := append([]string{}, implicitFields...)
+
+	for _, owner := range entity.BelongsTo() {
+		indexable = append(indexable, owner.Name+"Id")
+	}
+
+	for _, field := range entity.Fields {
+		indexable = append(indexable, field.Name)
+	}
+
+	for index, entityIndex := range entity.Indexes {
+		for fieldIndex, field := range entityIndex.Fields {
+			if !contains(indexable, field.Name) {
+				errors = append(errors, &FieldError{
+					Field:      fmt.Sprintf("%s.indexes[%v].fields[%v].name", path, index, fieldIndex),
+					Tag:        "field",
+					Value:      field.Name,
+					Message:    fmt.Sprintf("unknown field %q in an index of entity %q", field.Name, entity.Name),
+					Suggestion: suggest(field.Name, indexable),
+				})
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateBounds checks the values of the min, max, len, gt, gte, lt and lte
+// validations of a field, which must be integers with the lower bound not
+// greater than the upper one, otherwise no example value can be generated
+func validateBounds(field *entities.Field, path string) []*FieldError {
+	errors := make([]*FieldError, 0)
+	lower, upper := math.MinInt64, math.MaxInt64
+	lowerName, upperName := "", ""
+
+	for index, validation := range field.Validations {
+		if !contains(entities.BoundValidations, validation.Name) {
+			continue
+		}
+
+		value, err := strconv.Atoi(validation.Value)
+
+		if err != nil {
+			errors = append(errors, &FieldError{
+				Field:   fmt.Sprintf("%s.validations[%v].value", path, index),
+				Tag:     "integer",
+				Value:   validation.Value,
+				Message: fmt.Sprintf("the value of the %s validation of field %q must be an integer, got %q", validation.Name, field.Name, validation.Value),
+			})
+			continue
+		}
+
+		switch validation.Name {
+		case "min", "gte", "gt", "len":
+			if validation.Name == "gt" {
+				value++
+			}
+
+			if value > lower {
+				lower, lowerName = value, validation.Name
+			}
+		}
+
+		switch validation.Name {
+		case "max", "lte", "lt", "len":
+			if validation.Name == "lt" {
+				value--
+			}
+
+			if value < upper {
+				upper, upperName = value, validation.Name
+			}
+		}
+	}
+
+	if lowerName != "" && upperName != "" && lower > upper {
+		errors = append(errors, &FieldError{
+			Field:   path + ".validations",
+			Tag:     "bounds",
+			Value:   fmt.Sprintf("%s %s", lowerName, upperName),
+			Message: fmt.Sprintf("field %q can not satisfy both its %s and %s validations", field.Name, lowerName, upperName),
+		})
+	}
+
+	return errors
+}
+
+func unknownEntity(path string, name string, names []string) *FieldError {
+	return &FieldError{
+		Field:      path,
+		Tag:        "entity",
+		Value:      name,
+		Message:    fmt.Sprintf("unknown entity %q", name),
+		Suggestion: suggest(name, names),
+	}
+}
+
+func findField(entity *entities.Entity, name string) *entities.Field {
+	for _, field := range entity.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func NewReader() Reader {
 	return &reader{}
 }
@@ -0,0 +1,589 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type detectFormatTestCase struct {
+	Description string
+	Data        string
+	Expected    Format
+}
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []*detectFormatTestCase{
+		{
+			Description: "json object",
+			Data:        "\n  {\"app\": {}}",
+			Expected:    FormatJSON,
+		},
+		{
+			Description: "toml key after a comment",
+			Data:        "# definitions\nversion = \"1.0.0\"\n",
+			Expected:    FormatTOML,
+		},
+		{
+			Description: "toml table",
+			Data:        "[app]\nname = \"todoapp\"\n",
+			Expected:    FormatTOML,
+		},
+		{
+			Description: "yaml mapping",
+			Data:        "# definitions\nversion: 1.0.0\napp:\n  name: todoapp\n",
+			Expected:    FormatYAML,
+		},
+		{
+			Description: "yaml string containing an equals sign",
+			Data:        "version: \"a = b\"\n",
+			Expected:    FormatYAML,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			result := DetectFormat([]byte(testCase.Data))
+
+			if result != testCase.Expected {
+				t.Fatalf("expected format %s, got %s", testCase.Expected, result)
+			}
+		})
+	}
+}
+
+const jsonDefinition = `{
+	"version": "1.0.0",
+	"app": {
+		"name": "todoapp",
+		"stack": {"language": "go", "database": "mongodb"},
+		"entities": [
+			{
+				"name": "project",
+				"fields": [
+					{
+						"name": "name",
+						"type": "string",
+						"validations": [
+							{"name": "required", "value": "true"},
+							{"name": "min", "value": "3"}
+						]
+					}
+				],
+				"actions": [{"type": "create", "authenticated": true}],
+				"persisted": true
+			}
+		],
+		"authentication": {"entity": "project"}
+	}
+}`
+
+const yamlDefinition = `# a comment
+version: 1.0.0
+app:
+  name: todoapp
+  stack: { language: go, database: mongodb }
+  entities:
+    - name: project
+      fields:
+        - name: name
+          type: string
+          validations:
+            - { name: required, value: true }
+            - { name: min, value: 3 }
+      actions:
+        - { type: create, authenticated: true }
+      persisted: true
+  authentication:
+    entity: project
+`
+
+const tomlDefinition = `version = "1.0.0"
+
+[app]
+name = "todoapp"
+stack = { language = "go", database = "mongodb" }
+authentication = { entity = "project" }
+
+[[app.entities]]
+name = "project"
+persisted = true
+actions = [{ type = "create", authenticated = true }]
+
+[[app.entities.fields]]
+name = "name"
+type = "string"
+validations = [
+  { name = "required", value = true },
+  { name = "min", value = 3 },
+]
+`
+
+type readTestCase struct {
+	Description string
+	Data        string
+	Format      Format
+}
+
+func TestReadFormats(t *testing.T) {
+	var expected entities.Definitions
+	_, err := NewReader().ReadFormat([]byte(jsonDefinition), FormatJSON, &expected)
+
+	if err != nil {
+		t.Fatalf("reading json definition: %s", err)
+	}
+
+	testCases := []*readTestCase{
+		{
+			Description: "sniffed json",
+			Data:        jsonDefinition,
+		},
+		{
+			Description: "yaml",
+			Data:        yamlDefinition,
+			Format:      FormatYAML,
+		},
+		{
+			Description: "sniffed yaml",
+			Data:        yamlDefinition,
+		},
+		{
+			Description: "toml",
+			Data:        tomlDefinition,
+			Format:      FormatTOML,
+		},
+		{
+			Description: "sniffed toml",
+			Data:        tomlDefinition,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var result entities.Definitions
+			r := NewReader()
+
+			if testCase.Format == "" {
+				_, err = r.Read([]byte(testCase.Data), &result)
+			} else {
+				_, err = r.ReadFormat([]byte(testCase.Data), testCase.Format, &result)
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expectedJSON, _ := json.Marshal(expected)
+			resultJSON, _ := json.Marshal(result)
+
+			if string(expectedJSON) != string(resultJSON) {
+				t.Fatalf("expected %s, got %s", expectedJSON, resultJSON)
+			}
+
+			entity := result.App.Entities[0]
+
+			if entity.Definitions != &result {
+				t.Fatalf("entity %s is not linked to its definitions", entity.Name)
+			}
+
+			if entity.Actions[0].Entity != entity {
+				t.Fatalf("action %s is not linked to its entity", entity.Actions[0].Type)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	var fromJSON, fromYAML entities.Definitions
+	r := NewReader()
+
+	if _, err := r.ReadFile("../../_examples/todoapp.json", &fromJSON); err != nil {
+		t.Fatalf("reading json example: %s", err)
+	}
+
+	if _, err := r.ReadFile("../../_examples/todoapp.yaml", &fromYAML); err != nil {
+		t.Fatalf("reading yaml example: %s", err)
+	}
+
+	expected, _ := json.Marshal(fromJSON)
+	result, _ := json.Marshal(fromYAML)
+
+	if string(expected) != string(result) {
+		t.Fatalf("yaml example differs from the json one:\n%s\n%s", expected, result)
+	}
+}
+
+func TestReadInvalidValidationValue(t *testing.T) {
+	data := "app:\n  entities:\n    - fields:\n        - validations:\n            - { name: min, value: [3] }\n"
+
+	var result entities.Definitions
+	_, err := NewReader().Read([]byte(data), &result)
+
+	if err == nil {
+		t.Fatal("expected an error for a list validation value")
+	}
+}
+
+func TestReadNumberValidationValues(t *testing.T) {
+	testCases := []struct {
+		Description string
+		Value       string
+		Expected    string
+		Error       bool
+	}{
+		{
+			Description: "integer",
+			Value:       "max: 3",
+			Expected:    "3",
+		},
+		{
+			Description: "exponent",
+			Value:       "len: 1e3",
+			Expected:    "1000",
+		},
+		{
+			Description: "fraction",
+			Value:       "max: 2.5",
+			Error:       true,
+		},
+		{
+			Description: "fraction of an equality",
+			Value:       "eq: 2.5",
+			Expected:    "2.5",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			parts := strings.SplitN(testCase.Value, ": ", 2)
+			data := fmt.Sprintf("app:\n  entities:\n    - fields:\n        - validations:\n            - { name: %s, value: %s }\n", parts[0], parts[1])
+
+			var result entities.Definitions
+			_, err := NewReader().Read([]byte(data), &result)
+
+			if (err != nil) != testCase.Error {
+				t.Fatalf("expected error %v, got %v", testCase.Error, err)
+			}
+
+			if err == nil {
+				if value := result.App.Entities[0].Fields[0].Validations[0].Value; value != testCase.Expected {
+					t.Errorf("expected the value %s, got %s", testCase.Expected, value)
+				}
+			}
+		})
+	}
+}
+
+type validateTestCase struct {
+	Description    string
+	Data           string
+	ExpectedErrors []*FieldError
+}
+
+const validDefinition = `
+version: 1.0.0
+app:
+  name: todoapp
+  stack: { language: go, database: mongodb }
+  entities:
+    - name: user
+      fields:
+        - { name: name, type: string }
+        - { name: email, type: string }
+        - { name: password, type: string }
+      actions:
+        - { type: create, output: { entity: userInfo } }
+      persisted: true
+    - name: userInfo
+      fields:
+        - { name: name, type: string }
+        - { name: id, type: string }
+    - name: project
+      fields:
+        - { name: name, type: string }
+      actions:
+        - { type: getAll }
+      indexes:
+        - fields: [{ name: userId }, { name: createdAt }, { name: name }]
+      persisted: true
+  relationships:
+    - { item1: user, item2: project, type: hasMany }
+  authentication:
+    entity: user
+`
+
+func TestValidate(t *testing.T) {
+	testCases := []*validateTestCase{
+		{
+			Description: "valid definition",
+			Data:        validDefinition,
+		},
+		{
+			Description: "duplicated entity",
+			Data:        strings.Replace(validDefinition, "name: userInfo", "name: user", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[1].name", Tag: "unique", Value: "user"},
+			},
+		},
+		{
+			Description: "unknown relationship entities",
+			Data:        strings.Replace(validDefinition, "item1: user, item2: project", "item1: usr, item2: projects", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.relationships[0].item1", Tag: "entity", Value: "usr"},
+				{Field: "app.relationships[0].item2", Tag: "entity", Value: "projects"},
+			},
+		},
+		{
+			Description: "unknown authentication entity",
+			Data:        strings.Replace(validDefinition, "entity: user\n", "entity: account\n", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.authentication.entity", Tag: "entity", Value: "account"},
+			},
+		},
+		{
+			Description: "authentication entity without password",
+			Data:        strings.Replace(validDefinition, "name: password", "name: secret", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.authentication.entity", Tag: "has field", Value: "password"},
+			},
+		},
+		{
+			Description: "duplicated field and unknown type",
+			Data:        strings.Replace(validDefinition, "{ name: email, type: string }", "{ name: name, type: text }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].fields[1].name", Tag: "unique", Value: "name"},
+				{Field: "app.entities[0].fields[1].type", Tag: "oneof", Value: strings.Join(entities.FieldTypes, " ")},
+				{Field: "app.authentication.entity", Tag: "has field", Value: "email"},
+			},
+		},
+		{
+			Description: "unknown action type",
+			Data:        strings.Replace(validDefinition, "type: getAll", "type: list", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[2].actions[0].type", Tag: "oneof", Value: strings.Join(entities.ActionTypes, " ")},
+			},
+		},
+		{
+			Description: "unknown output entity",
+			Data:        strings.Replace(validDefinition, "output: { entity: userInfo }", "output: { entity: userData }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].actions[0].output.entity", Tag: "entity", Value: "userData"},
+			},
+		},
+		{
+			Description: "unknown input entity",
+			Data:        strings.Replace(validDefinition, "output: { entity: userInfo }", "input: { entity: userData }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].actions[0].input.entity", Tag: "entity", Value: "userData"},
+			},
+		},
+		{
+			Description: "output entity with fields not in the source entity",
+			Data:        strings.Replace(validDefinition, "{ name: id, type: string }", "{ name: age, type: int }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].actions[0].output.entity", Tag: "subset", Value: "user"},
+			},
+		},
+		{
+			Description: "unknown index field",
+			Data:        strings.Replace(validDefinition, "{ name: userId }", "{ name: ownerId }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[2].indexes[0].fields[0].name", Tag: "field", Value: "ownerId"},
+			},
+		},
+		{
+			Description: "non integer bound value",
+			Data:        strings.Replace(validDefinition, "{ name: password, type: string }", "{ name: password, type: string, validations: [{ name: min, value: eight }] }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].fields[2].validations[0].value", Tag: "integer", Value: "eight"},
+			},
+		},
+		{
+			Description: "min greater than max",
+			Data:        strings.Replace(validDefinition, "{ name: password, type: string }", "{ name: password, type: string, validations: [{ name: min, value: 10 }, { name: lt, value: 10 }] }", 1),
+			ExpectedErrors: []*FieldError{
+				{Field: "app.entities[0].fields[2].validations", Tag: "bounds", Value: "min lt"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var definitions entities.Definitions
+			r := NewReader()
+
+			positions, err := r.Read([]byte(testCase.Data), &definitions)
+
+			if err != nil {
+				t.Fatalf("reading definition: %s", err)
+			}
+
+			result := r.Validate(&definitions, positions)
+
+			if len(testCase.ExpectedErrors) == 0 {
+				if result != nil {
+					expected, _ := json.Marshal(result.Errors)
+					t.Fatalf("unexpected errors: %s", expected)
+				}
+				return
+			}
+
+			if result == nil {
+				t.Fatal("expected validation errors")
+			}
+
+			// Only the error identity is checked here, see TestValidateMessages
+			for _, fieldErr := range result.Errors {
+				fieldErr.Path, fieldErr.Line, fieldErr.Column = "", 0, 0
+				fieldErr.Message, fieldErr.Suggestion = "", ""
+			}
+
+			expected, _ := json.Marshal(testCase.ExpectedErrors)
+			errors, _ := json.Marshal(result.Errors)
+
+			if string(expected) != string(errors) {
+				t.Fatalf("expected errors %s, got %s", expected, errors)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutApp(t *testing.T) {
+	result := NewReader().Validate(&entities.Definitions{}, nil)
+
+	if result == nil || len(result.Errors) != 1 || result.Errors[0].Tag != "required" || result.Errors[0].Path != "app" {
+		expected, _ := json.Marshal(result)
+		t.Fatalf("expected the app to be required, got %s", expected)
+	}
+}
+
+type validateMessagesTestCase struct {
+	Description string
+	Data        string
+	Expected    *FieldError
+}
+
+func TestValidateMessages(t *testing.T) {
+	testCases := []*validateMessagesTestCase{
+		{
+			Description: "misspelled relationship entity in yaml",
+			Data:        strings.Replace(validDefinition, "item1: user, item2: project", "item1: usr, item2: project", 1),
+			Expected: &FieldError{
+				Path:       "app.relationships[0].item1",
+				Line:       28,
+				Column:     16,
+				Message:    `unknown entity "usr"`,
+				Suggestion: "user",
+			},
+		},
+		{
+			Description: "misspelled index field",
+			Data:        strings.Replace(validDefinition, "{ name: createdAt }", "{ name: createAt }", 1),
+			Expected: &FieldError{
+				Path:       "app.entities[2].indexes[0].fields[1].name",
+				Line:       25,
+				Column:     46,
+				Message:    `unknown field "createAt" in an index of entity "project"`,
+				Suggestion: "createdAt",
+			},
+		},
+		{
+			Description: "struct tag validation in json",
+			Data:        "{\n  \"app\": {\n    \"name\": \"ab\"\n  }\n}",
+			Expected: &FieldError{
+				Path:    "app.name",
+				Line:    3,
+				Column:  13,
+				Message: "must have at least 3 characters",
+			},
+		},
+		{
+			Description: "missing required value points to its parent",
+			Data:        "{\"app\": {\"name\": \"todoapp\",\n  \"relationships\": [\n    {\"item1\": \"a\", \"type\": \"hasOne\"}]}}",
+			Expected: &FieldError{
+				Path:    "app.relationships[0].item2",
+				Line:    3,
+				Column:  5,
+				Message: "is required",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var definitions entities.Definitions
+			r := NewReader()
+
+			positions, err := r.Read([]byte(testCase.Data), &definitions)
+
+			if err != nil {
+				t.Fatalf("reading definition: %s", err)
+			}
+
+			result := r.Validate(&definitions, positions)
+
+			if result == nil {
+				t.Fatal("expected validation errors")
+			}
+
+			fieldErr := result.Errors[0]
+			fieldErr.Field, fieldErr.Tag, fieldErr.Value = "", "", ""
+
+			expected, _ := json.Marshal(testCase.Expected)
+			got, _ := json.Marshal(fieldErr)
+
+			if string(expected) != string(got) {
+				t.Fatalf("expected error %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+type suggestTestCase struct {
+	Description string
+	Value       string
+	Candidates  []string
+	Expected    string
+}
+
+func TestSuggest(t *testing.T) {
+	testCases := []*suggestTestCase{
+		{
+			Description: "one letter missing",
+			Value:       "projct",
+			Candidates:  []string{"user", "project", "task"},
+			Expected:    "project",
+		},
+		{
+			Description: "swapped letters",
+			Value:       "usre",
+			Candidates:  []string{"user", "project"},
+			Expected:    "user",
+		},
+		{
+			Description: "different case",
+			Value:       "UserInfo",
+			Candidates:  []string{"user", "userInfo"},
+			Expected:    "userInfo",
+		},
+		{
+			Description: "nothing close",
+			Value:       "invoice",
+			Candidates:  []string{"user", "project"},
+			Expected:    "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			result := suggest(testCase.Value, testCase.Candidates)
+
+			if result != testCase.Expected {
+				t.Fatalf("expected suggestion %q, got %q", testCase.Expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package reader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version of the definitions format produced by this engine. Definitions with
+// an older version are upgraded when read, newer ones are rejected.
+const CurrentVersion = "1.0.0"
+
+// Version assumed for definitions that do not declare one, written before the
+// version was checked
+const initialVersion = "1.0.0"
+
+// UpgradeFunc converts a decoded definitions document from one version of the
+// format to the next one, in place
+type UpgradeFunc func(document map[string]interface{}) error
+
+type upgrade struct {
+	From  string
+	To    string
+	Apply UpgradeFunc
+}
+
+var upgrades = make([]*upgrade, 0)
+
+// RegisterUpgrade adds a function that converts definitions of version from to
+// version to. Upgrades are chained until the document reaches CurrentVersion.
+// It panics if an upgrade from the same version was already registered.
+func RegisterUpgrade(from string, to string, apply UpgradeFunc) {
+	if apply == nil {
+		panic(fmt.Sprintf("reader: nil upgrade from version %s", from))
+	}
+
+	if findUpgrade(from) != nil {
+		panic(fmt.Sprintf("reader: upgrade from version %s registered twice", from))
+	}
+
+	if compareVersions(from, to) >= 0 {
+		panic(fmt.Sprintf("reader: upgrade from version %s to %s does not move forward", from, to))
+	}
+
+	upgrades = append(upgrades, &upgrade{From: from, To: to, Apply: apply})
+}
+
+func findUpgrade(from string) *upgrade {
+	for _, u := range upgrades {
+		if u.From == from {
+			return u
+		}
+	}
+	return nil
+}
+
+// SupportedVersions lists the definition versions the reader can read, oldest first
+func SupportedVersions() []string {
+	result := make([]string, 0)
+	version := CurrentVersion
+
+	// Walk back from the current version through the registered upgrades
+	for {
+		result = append([]string{version}, result...)
+		previous := ""
+
+		for _, u := range upgrades {
+			if u.To == version {
+				previous = u.From
+			}
+		}
+
+		if previous == "" {
+			return result
+		}
+
+		version = previous
+	}
+}
+
+// upgradeDocument runs the registered upgrades on a decoded document until it
+// reaches CurrentVersion. It returns the version the document had, empty when
+// it did not declare one.
+func upgradeDocument(document map[string]interface{}) (string, error) {
+	original := documentVersion(document)
+	version := original
+
+	if version == "" {
+		version = initialVersion
+	}
+
+	for {
+		comparison, err := checkVersion(version)
+
+		if err != nil {
+			return original, err
+		}
+
+		if comparison > 0 {
+			return original, fmt.Errorf("definition version %s is newer than the supported version %s, update fancybuild to read it", version, CurrentVersion)
+		}
+
+		if comparison == 0 {
+			document["version"] = CurrentVersion
+			return original, nil
+		}
+
+		u := findUpgrade(version)
+
+		if u == nil {
+			return original, fmt.Errorf("unsupported definition version %s, supported versions are: %s", version, strings.Join(SupportedVersions(), ", "))
+		}
+
+		if err := u.Apply(document); err != nil {
+			return original, fmt.Errorf("on upgrading definition from version %s to %s: %w", u.From, u.To, err)
+		}
+
+		version = u.To
+	}
+}
+
+func documentVersion(document map[string]interface{}) string {
+	version, ok := document["version"]
+
+	if !ok || version == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", version)
+}
+
+// checkVersion compares a version to the current one
+func checkVersion(version string) (int, error) {
+	if _, err := parseVersion(version); err != nil {
+		return 0, err
+	}
+	return compareVersions(version, CurrentVersion), nil
+}
+
+func parseVersion(version string) ([3]int, error) {
+	var result [3]int
+	parts := strings.Split(version, ".")
+
+	if len(parts) > 3 {
+		return result, fmt.Errorf("invalid definition version %q, expected major.minor.patch", version)
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+
+		if err != nil || n < 0 {
+			return result, fmt.Errorf("invalid definition version %q, expected major.minor.patch", version)
+		}
+
+		result[i] = n
+	}
+
+	return result, nil
+}
+
+// compareVersions returns -1, 0 or 1 when a is older, equal or newer than b.
+// Invalid versions compare as 0.0.0.
+func compareVersions(a string, b string) int {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// Upgrade converts a definitions document to CurrentVersion, keeping its format.
+// It reports whether the document changed; unchanged documents are returned as is.
+// Comments of YAML and TOML documents are not kept on upgraded documents.
+func Upgrade(data []byte, format Format) ([]byte, bool, error) {
+	document, err := decode(data, format)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("error while parsing %s data: %w", format, err)
+	}
+
+	object, ok := document.(map[string]interface{})
+
+	if !ok {
+		return nil, false, fmt.Errorf("error while parsing %s data: expected an object", format)
+	}
+
+	original, err := upgradeDocument(object)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if original == CurrentVersion {
+		return data, false, nil
+	}
+
+	result, err := encode(object, format)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("error while encoding %s data: %w", format, err)
+	}
+
+	return result, true, nil
+}
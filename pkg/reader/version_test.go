@@ -0,0 +1,134 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type upgradeTestCase struct {
+	Description     string
+	Data            string
+	Format          Format
+	ExpectedChanged bool
+	ExpectedError   string
+	ExpectedContent string
+}
+
+// withUpgrades replaces the registered upgrades during a test
+func withUpgrades(t *testing.T, register func()) {
+	previous := upgrades
+	upgrades = make([]*upgrade, 0)
+	t.Cleanup(func() { upgrades = previous })
+	register()
+}
+
+func TestUpgrade(t *testing.T) {
+	withUpgrades(t, func() {
+		RegisterUpgrade("0.8.0", "0.9.0", func(document map[string]interface{}) error {
+			app := document["app"].(map[string]interface{})
+			app["entities"] = app["models"]
+			delete(app, "models")
+			return nil
+		})
+		RegisterUpgrade("0.9.0", CurrentVersion, func(document map[string]interface{}) error {
+			return nil
+		})
+	})
+
+	testCases := []*upgradeTestCase{
+		{
+			Description:     "current version",
+			Data:            `{"version": "1.0.0", "app": {"name": "todo"}}`,
+			Format:          FormatJSON,
+			ExpectedContent: `{"version": "1.0.0", "app": {"name": "todo"}}`,
+		},
+		{
+			Description:     "missing version",
+			Data:            "app:\n  name: todo\n",
+			Format:          FormatYAML,
+			ExpectedChanged: true,
+			ExpectedContent: "app:\n  name: todo\nversion: 1.0.0\n",
+		},
+		{
+			Description:     "chained upgrades",
+			Data:            `{"version": "0.8.0", "app": {"name": "todo", "models": [{"name": "task"}]}}`,
+			Format:          FormatJSON,
+			ExpectedChanged: true,
+			ExpectedContent: "{\n    \"app\": {\n        \"entities\": [\n            {\n                \"name\": \"task\"\n            }\n        ],\n        \"name\": \"todo\"\n    },\n    \"version\": \"1.0.0\"\n}\n",
+		},
+		{
+			Description:     "toml tables",
+			Data:            "version = \"0.9.0\"\n[app]\nname = \"todo\"\n[[app.entities]]\nname = \"task\"\n",
+			Format:          FormatTOML,
+			ExpectedChanged: true,
+			ExpectedContent: "version = \"1.0.0\"\n\n[app]\n  name = \"todo\"\n\n  [[app.entities]]\n    name = \"task\"\n",
+		},
+		{
+			Description:   "newer version",
+			Data:          `{"version": "2.0.0"}`,
+			Format:        FormatJSON,
+			ExpectedError: "definition version 2.0.0 is newer than the supported version 1.0.0",
+		},
+		{
+			Description:   "version without upgrade",
+			Data:          `{"version": "0.5.0"}`,
+			Format:        FormatJSON,
+			ExpectedError: "unsupported definition version 0.5.0, supported versions are: 0.8.0, 0.9.0, 1.0.0",
+		},
+		{
+			Description:   "invalid version",
+			Data:          `{"version": "latest"}`,
+			Format:        FormatJSON,
+			ExpectedError: `invalid definition version "latest"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			result, changed, err := Upgrade([]byte(testCase.Data), testCase.Format)
+
+			if testCase.ExpectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.ExpectedError) {
+					t.Fatalf("expected error %q, got %v", testCase.ExpectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if changed != testCase.ExpectedChanged {
+				t.Fatalf("expected changed to be %v", testCase.ExpectedChanged)
+			}
+
+			if string(result) != testCase.ExpectedContent {
+				t.Fatalf("expected content %q, got %q", testCase.ExpectedContent, result)
+			}
+		})
+	}
+}
+
+func TestReadUpgradesDefinitions(t *testing.T) {
+	withUpgrades(t, func() {
+		RegisterUpgrade("0.9.0", CurrentVersion, func(document map[string]interface{}) error {
+			app := document["app"].(map[string]interface{})
+			app["name"] = app["title"]
+			delete(app, "title")
+			return nil
+		})
+	})
+
+	var definitions entities.Definitions
+	_, err := NewReader().Read([]byte(`{"version": "0.9.0", "app": {"title": "todo"}}`), &definitions)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if definitions.Version != CurrentVersion || definitions.App.Name != "todo" {
+		t.Fatalf("definitions were not upgraded: version %s, name %s", definitions.Version, definitions.App.Name)
+	}
+}
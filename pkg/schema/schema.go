@@ -0,0 +1,203 @@
+// Package schema generates the JSON Schema of the definitions format from the
+// structs of the entities package, so editors can validate and autocomplete
+// definition files.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Draft of the JSON Schema specification the generated schema follows
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// A JSON Schema document, limited to the keywords used for the definitions
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"` // false or a *Schema
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Enums implied by the code but not declared in the validate tags, keyed by
+// "<struct>.<field>"
+var enums = map[string][]string{
+	"Action.Type":     entities.ActionTypes,
+	"Field.Type":      entities.FieldTypes,
+	"IndexField.Sort": entities.IndexSorts,
+	"Validation.Name": entities.ValidationNames,
+}
+
+// Types that accept more than the Go type of the field, keyed by "<struct>.<field>"
+var types = map[string]interface{}{
+	"Validation.Value": []string{"string", "number", "boolean"},
+}
+
+var descriptions = map[string]string{
+	"Definitions":    "Definitions of a web application generated by fancybuild",
+	"App":            "The application to be generated",
+	"Stack":          "The language, web framework and database of the generated project",
+	"Entity":         "Single entity of the project, e.g. user, sale or product",
+	"Field":          "A field of an entity",
+	"Validation":     "Single validation of a field",
+	"Action":         "An action of the API implemented for an entity",
+	"Input":          "Entity used to send data to an action instead of the action entity",
+	"Output":         "Entity returned by an action instead of the action entity",
+	"Index":          "A database index of an entity",
+	"IndexField":     "A field of an index",
+	"Relationship":   "A relationship between two entities",
+	"Authentication": "The authentication and authorization specifications of the project",
+	"Mixin":          "Reusable part of an entity, applied to the entities listing it in their mixins",
+}
+
+// Generate builds the schema of entities.Definitions
+func Generate() *Schema {
+	defs := make(map[string]*Schema)
+	root := structSchema(reflect.TypeOf(entities.Definitions{}), defs)
+
+	// Lets editors associate a definition file to this schema
+	root.Properties["$schema"] = &Schema{Type: "string"}
+
+	root.Schema = Draft
+	root.Title = "fancybuild definitions"
+	root.Defs = defs
+
+	return root
+}
+
+// JSON returns the indented JSON encoding of the definitions schema
+func JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Generate(), "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func structSchema(t reflect.Type, defs map[string]*Schema) *Schema {
+	result := &Schema{
+		Type:                 "object",
+		Description:          descriptions[t.Name()],
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.PkgPath != "" || name == "-" || field.Tag.Get("validate") == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		key := t.Name() + "." + field.Name
+		property := typeSchema(field.Type, defs)
+
+		if enum, ok := enums[key]; ok {
+			property.Enum = enum
+		}
+
+		if fieldType, ok := types[key]; ok {
+			property.Type = fieldType
+		}
+
+		if applyTag(property, field.Tag.Get("validate")) {
+			result.Required = append(result.Required, name)
+		}
+
+		result.Properties[name] = property
+	}
+
+	return result
+}
+
+func typeSchema(t reflect.Type, defs map[string]*Schema) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			// Reserve the name before walking the fields, so recursive types terminate
+			defs[t.Name()] = nil
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return &Schema{Ref: "#/$defs/" + t.Name()}
+	}
+	return &Schema{}
+}
+
+// applyTag translates the validator tag of a field into schema keywords and
+// reports whether the field is required
+func applyTag(s *Schema, tag string) bool {
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, value := rule, ""
+
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, value = rule[:i], rule[i+1:]
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "oneof":
+			s.Enum = strings.Fields(value)
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			limit(s, name, n)
+		}
+	}
+
+	return required
+}
+
+func limit(s *Schema, name string, n int) {
+	switch {
+	case s.Type == "string" && name == "min":
+		s.MinLength = &n
+	case s.Type == "string" && name == "max":
+		s.MaxLength = &n
+	case s.Type == "array" && name == "min":
+		s.MinItems = &n
+	case s.Type == "array" && name == "max":
+		s.MaxItems = &n
+	}
+}
@@ -0,0 +1,193 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+const schemaFile = "../../definitions.schema.json"
+
+func TestSchemaInSync(t *testing.T) {
+	expected, err := JSON()
+
+	if err != nil {
+		t.Fatalf("generating schema: %s", err)
+	}
+
+	current, err := os.ReadFile(schemaFile)
+
+	if err != nil {
+		t.Fatalf("reading schema: %s", err)
+	}
+
+	if string(current) != string(expected) {
+		t.Fatalf("%s is out of date, run: go run ./cmd/fancybuild schema > definitions.schema.json", schemaFile)
+	}
+}
+
+type conformTestCase struct {
+	Description   string
+	Document      string
+	ExpectedError string
+}
+
+func TestSchemaConformance(t *testing.T) {
+	s := Generate()
+
+	testCases := []*conformTestCase{
+		{
+			Description: "valid document",
+			Document:    `{"$schema": "x", "version": "1.0.0", "app": {"name": "todoapp", "relationships": [{"item1": "a", "item2": "b", "type": "hasOne"}]}}`,
+		},
+		{
+			Description:   "unknown property",
+			Document:      `{"app": {"name": "todoapp", "entites": []}}`,
+			ExpectedError: "app.entites: unknown property",
+		},
+		{
+			Description:   "unknown action type",
+			Document:      `{"app": {"entities": [{"actions": [{"type": "list"}]}]}}`,
+			ExpectedError: "app.entities[0].actions[0].type: \"list\" is not one of",
+		},
+		{
+			Description:   "missing required property",
+			Document:      `{"app": {"relationships": [{"item1": "a", "type": "hasOne"}]}}`,
+			ExpectedError: "app.relationships[0]: missing item2",
+		},
+		{
+			Description: "field sets and mixins",
+			Document:    `{"app": {"fieldSets": {"credentials": [{"name": "email"}]}, "mixins": {"auditable": {"timestamps": true}}, "entities": [{"mixins": ["auditable"]}]}}`,
+		},
+		{
+			Description:   "unknown mixin property",
+			Document:      `{"app": {"mixins": {"auditable": {"timestamp": true}}}}`,
+			ExpectedError: "app.mixins.auditable.timestamp: unknown property",
+		},
+		{
+			Description: "numeric validation value",
+			Document:    `{"app": {"entities": [{"fields": [{"validations": [{"name": "min", "value": 3}]}]}]}}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			var document interface{}
+			if err := json.Unmarshal([]byte(testCase.Document), &document); err != nil {
+				t.Fatalf("invalid test document: %s", err)
+			}
+
+			err := conform(s, s, document, "")
+
+			if testCase.ExpectedError == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if testCase.ExpectedError != "" && (err == nil || !strings.Contains(err.Error(), testCase.ExpectedError)) {
+				t.Fatalf("expected error %q, got %v", testCase.ExpectedError, err)
+			}
+		})
+	}
+}
+
+func TestExamplesConform(t *testing.T) {
+	s := Generate()
+
+	for _, file := range []string{"blog.json", "todoapp.json", "ecommerce.json"} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile("../../_examples/" + file)
+
+			if err != nil {
+				t.Fatalf("reading example: %s", err)
+			}
+
+			var document interface{}
+			if err := json.Unmarshal(data, &document); err != nil {
+				t.Fatalf("parsing example: %s", err)
+			}
+
+			if err := conform(s, s, document, ""); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// conform checks a decoded JSON document against the subset of keywords the
+// generated schema uses
+func conform(root, s *Schema, value interface{}, path string) error {
+	if s.Ref != "" {
+		return conform(root, root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")], value, path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if s.Type != "object" {
+			return fmt.Errorf("%s: unexpected object", path)
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing %s", path, name)
+			}
+		}
+		for key, item := range v {
+			property, ok := s.Properties[key]
+			if additional, isSchema := s.AdditionalProperties.(*Schema); !ok && isSchema {
+				property, ok = additional, true
+			}
+			if !ok {
+				return fmt.Errorf("%s: unknown property", strings.TrimPrefix(path+"."+key, "."))
+			}
+			if err := conform(root, property, item, strings.TrimPrefix(path+"."+key, ".")); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Type != "array" {
+			return fmt.Errorf("%s: unexpected array", path)
+		}
+		for i, item := range v {
+			if err := conform(root, s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case string:
+		if !allows(s.Type, "string") {
+			return fmt.Errorf("%s: unexpected string", path)
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, v) {
+			return fmt.Errorf("%s: %q is not one of %v", path, v, s.Enum)
+		}
+	case float64:
+		if !allows(s.Type, "number") && !allows(s.Type, "integer") {
+			return fmt.Errorf("%s: unexpected number", path)
+		}
+	case bool:
+		if !allows(s.Type, "boolean") {
+			return fmt.Errorf("%s: unexpected boolean", path)
+		}
+	}
+
+	return nil
+}
+
+func allows(schemaType interface{}, name string) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return t == name
+	case []string:
+		return contains(t, name)
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
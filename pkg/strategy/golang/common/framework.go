@@ -0,0 +1,57 @@
+package common
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// DefaultFramework - Framework of the definitions that do not choose one
+const DefaultFramework = "fiber"
+
+// Framework - What a Go stack takes from its web framework: the router, the
+// middleware, the controllers and the test utils
+type Framework struct {
+	Name    string   // Folder of the templates of the framework, e.g. "fiber"
+	Folders []string // Folders searched after the one of the framework, e.g. "nethttp"
+
+	// Files only the framework has. It may be nil.
+	Files func(*entities.Definitions) map[string]*entities.File
+}
+
+// Frameworks - The web frameworks of the Go stacks by name
+var Frameworks = map[string]*Framework{
+	"fiber": {Name: "fiber"},
+	"nethttp": {
+		Name: "nethttp",
+		Files: webFiles(map[string]string{
+			"web":        "pkg/web/web.go",
+			"middleware": "pkg/web/middleware.go",
+		}),
+	},
+	// chi routes the handlers of the standard library, so it shares the
+	// templates of nethttp besides its router and middleware
+	"chi": {
+		Name:    "chi",
+		Folders: []string{"nethttp"},
+		Files: webFiles(map[string]string{
+			"web": "pkg/web/web.go",
+		}),
+	},
+}
+
+// Files of the package adapting the handlers that return errors to net/http,
+// their templates are in go/nethttp
+func webFiles(paths map[string]string) func(*entities.Definitions) map[string]*entities.File {
+	return func(definitions *entities.Definitions) map[string]*entities.File {
+		fileMap := make(map[string]*entities.File, len(paths))
+
+		for key, path := range paths {
+			fileMap[key] = &entities.File{
+				FinalPath:    path,
+				TemplatePath: "go/nethttp/" + key + ".tmpl",
+				Data:         definitions,
+			}
+		}
+
+		return fileMap
+	}
+}
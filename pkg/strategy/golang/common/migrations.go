@@ -0,0 +1,383 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/diff"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Path of the schema of the migrations in the project
+const schemaPath = "migrations/schema.json"
+
+// Schema - What the migrations of a project were generated from. It is kept in
+// the project, so the next build keeps the migrations the app may have applied
+// as they are and only adds the ones for the changes of the tables.
+type Schema struct {
+	Migrations  []string              `json:"migrations"`  // Names of the migrations in order, e.g. "0001_create_users"
+	Definitions *entities.Definitions `json:"definitions"` // Entities and relationships of the last build
+}
+
+// TableChanges - The changes of the table of an entity since the last build,
+// applied by an alter migration
+type TableChanges struct {
+	Table          *Table          // The table after the changes
+	PreviousName   string          // Name of the table in the last build, the same unless the entity was renamed
+	Renamed        []*ColumnRename // Columns of renamed fields and entities
+	Removed        []*Column       // Columns as they were in the last build, after the renames
+	Added          []*Column
+	Retyped        []*ColumnType
+	RemovedIndexes []*Index
+	AddedIndexes   []*Index
+	AlterTypes     bool // Whether the database can change the type of a column
+}
+
+// ColumnRename - A column renamed since the last build
+type ColumnRename struct {
+	From string
+	To   string
+}
+
+// ColumnType - A column whose type changed since the last build
+type ColumnType struct {
+	Column *Column
+	From   string // Type of the column in the last build
+}
+
+// IsRenamed - Checks if the table itself was renamed
+func (c *TableChanges) IsRenamed() bool {
+	return c.PreviousName != c.Table.Name
+}
+
+func (c *TableChanges) isEmpty() bool {
+	return !c.IsRenamed() && len(c.Renamed) == 0 && len(c.Removed) == 0 && len(c.Added) == 0 &&
+		len(c.Retyped) == 0 && len(c.RemovedIndexes) == 0 && len(c.AddedIndexes) == 0
+}
+
+// A migration to generate, named without its version, e.g. "create_users"
+type migration struct {
+	name string
+	up   string // Template of the up file
+	down string // Template of the down file
+	data interface{}
+}
+
+// Data of the templates creating and dropping a table
+type tableData struct {
+	*entities.Definitions
+	*Table
+}
+
+// Migrations - Files of the migrations of the tables, of their schema and of
+// the package embedding them. The migrations of the last build are kept as
+// they were, since the app may have applied them, and the changes of the
+// tables since then are applied by new migrations.
+func (d Dialect) Migrations(definitions *entities.Definitions, previous Previous) (map[string]*entities.File, error) {
+	schema, err := readSchema(previous)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fileMap := map[string]*entities.File{
+		"migrations": {
+			FinalPath:    "migrations/migrations.go",
+			TemplatePath: "go/sql/migrations.tmpl",
+			Data:         definitions,
+		},
+	}
+
+	for _, name := range schema.Migrations {
+		for _, direction := range []string{"up", "down"} {
+			finalPath := migrationPath(name, direction)
+			content, err := previous(finalPath)
+
+			if err != nil {
+				return nil, fmt.Errorf("on reading migration %s: %w", name, err)
+			}
+
+			fileMap[migrationKey(name, direction)] = &entities.File{FinalPath: finalPath, Result: string(content)}
+		}
+	}
+
+	for _, m := range d.changes(schema.Definitions, definitions) {
+		name := fmt.Sprintf("%04d_%s", len(schema.Migrations)+1, m.name)
+		schema.Migrations = append(schema.Migrations, name)
+
+		for direction, template := range map[string]string{"up": m.up, "down": m.down} {
+			fileMap[migrationKey(name, direction)] = &entities.File{
+				FinalPath:    migrationPath(name, direction),
+				TemplatePath: template,
+				Data:         m.data,
+			}
+		}
+	}
+
+	// Only the entities and relationships are compared by the next build
+	schema.Definitions = &entities.Definitions{
+		App: &entities.App{
+			Name:          definitions.App.Name,
+			Entities:      definitions.App.Entities,
+			Relationships: definitions.App.Relationships,
+		},
+	}
+
+	content, err := json.MarshalIndent(schema, "", "  ")
+
+	if err != nil {
+		return nil, fmt.Errorf("on encoding the schema of the migrations: %v", err)
+	}
+
+	fileMap["migrations_schema"] = &entities.File{FinalPath: schemaPath, Result: string(content) + "\n"}
+	return fileMap, nil
+}
+
+func migrationKey(name string, direction string) string {
+	return fmt.Sprintf("migration_%s_%s", name, direction)
+}
+
+func migrationPath(name string, direction string) string {
+	return fmt.Sprintf("migrations/%s.%s.sql", name, direction)
+}
+
+// readSchema reads the schema of the last build, empty when there was none
+func readSchema(previous Previous) (*Schema, error) {
+	schema := &Schema{Migrations: make([]string, 0)}
+	content, err := previous(schemaPath)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return schema, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("on reading the schema of the migrations: %w", err)
+	}
+
+	if err := json.Unmarshal(content, schema); err != nil {
+		return nil, fmt.Errorf("on parsing %s: %v", schemaPath, err)
+	}
+
+	if schema.Definitions != nil && schema.Definitions.App != nil {
+		for _, entity := range schema.Definitions.App.Entities {
+			entity.Definitions = schema.Definitions
+		}
+	} else {
+		schema.Definitions = nil
+	}
+
+	return schema, nil
+}
+
+// changes returns the migrations from the tables of the previous definitions,
+// nil for the first build, to the ones of the next definitions. The new tables
+// are created first, since the changed ones may reference them, and the
+// removed tables are dropped last, once nothing references them.
+func (d Dialect) changes(previous, next *entities.Definitions) []*migration {
+	created := make([]*migration, 0)
+	altered := make([]*migration, 0)
+	dropped := make([]*migration, 0)
+
+	previousTables := make(map[string]*Table) // By the next name of the table
+	previousOrder := make([]*Table, 0)
+	renames := &renames{}
+
+	if previous != nil {
+		previousOrder = d.Tables(previous)
+		renames = findRenames(previous, next)
+
+		for _, table := range previousOrder {
+			previousTables[renames.table(table.Name)] = table
+		}
+	}
+
+	for _, table := range d.Tables(next) {
+		previousTable, ok := previousTables[table.Name]
+		delete(previousTables, table.Name)
+
+		if !ok {
+			created = append(created, &migration{
+				name: "create_" + table.Name,
+				up:   "go/sql/migration_up.tmpl",
+				down: "go/sql/migration_down.tmpl",
+				data: &tableData{next, table},
+			})
+			continue
+		}
+
+		changes := d.compare(previousTable, table, renames)
+
+		if !changes.isEmpty() {
+			altered = append(altered, &migration{
+				name: "alter_" + table.Name,
+				up:   "go/sql/migration_alter_up.tmpl",
+				down: "go/sql/migration_alter_down.tmpl",
+				data: changes,
+			})
+		}
+	}
+
+	// Tables referencing others come after them, so they are dropped first
+	for i := len(previousOrder) - 1; i >= 0; i-- {
+		table := previousOrder[i]
+
+		if _, ok := previousTables[renames.table(table.Name)]; !ok {
+			continue
+		}
+
+		dropped = append(dropped, &migration{
+			name: "drop_" + table.Name,
+			up:   "go/sql/migration_down.tmpl",
+			down: "go/sql/migration_up.tmpl",
+			data: &tableData{previous, table},
+		})
+	}
+
+	return append(append(created, altered...), dropped...)
+}
+
+// renames - Names of the tables and columns renamed since the last build
+type renames struct {
+	tables  map[string]string            // Previous table name to the next one
+	columns map[string]map[string]string // Previous column name to the next one, by next table name
+	parents map[string]string            // Columns named after renamed entities, e.g. foreign keys, previous name to the next one
+}
+
+func (r *renames) table(name string) string {
+	if renamed, ok := r.tables[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// column returns the next name of a column of a table, by the next name of the table
+func (r *renames) column(table string, name string) string {
+	if renamed, ok := r.columns[table][name]; ok {
+		return renamed
+	}
+
+	if renamed, ok := r.parents[name]; ok {
+		return renamed
+	}
+
+	return name
+}
+
+// findRenames finds the renamed entities and fields with the definitions diff.
+// A renamed entity renames its table and the columns named after it in the
+// tables of its relationships, e.g. its foreign key.
+func findRenames(previous, next *entities.Definitions) *renames {
+	result := &renames{
+		tables:  make(map[string]string),
+		columns: make(map[string]map[string]string),
+		parents: make(map[string]string),
+	}
+
+	for _, change := range diff.Compare(previous, next).Changes {
+		if change.Kind != diff.Renamed {
+			continue
+		}
+
+		switch change.Subject {
+		case diff.SubjectEntity:
+			result.tables[templates.Pluralize(change.OldName)] = templates.Pluralize(change.Name)
+			result.parents[change.OldName+"Id"] = change.Name + "Id"
+			result.parents[templates.Pluralize(change.OldName)] = templates.Pluralize(change.Name)
+			result.parents[change.OldName] = change.Name
+		case diff.SubjectField:
+			table := templates.Pluralize(change.Entity)
+
+			if result.columns[table] == nil {
+				result.columns[table] = make(map[string]string)
+			}
+
+			result.columns[table][change.OldName] = change.Name
+		}
+	}
+
+	return result
+}
+
+// compare finds the changes of a table since the last build. A column whose
+// reference changed is dropped and added again, a column whose type changed
+// is altered.
+func (d Dialect) compare(previous *Table, next *Table, renames *renames) *TableChanges {
+	changes := &TableChanges{Table: next, PreviousName: previous.Name, AlterTypes: d.AlterTypes}
+	matched := make(map[string]bool)
+
+	for _, column := range previous.Columns {
+		name := renames.column(next.Name, column.Name)
+		nextColumn := next.Column(name)
+
+		// Only renamed to a column that did not exist before, otherwise the column was removed
+		if name != column.Name && (nextColumn == nil || previous.Column(name) != nil) {
+			name = column.Name
+			nextColumn = next.Column(name)
+		}
+
+		if nextColumn == nil {
+			changes.Removed = append(changes.Removed, column)
+			continue
+		}
+
+		matched[name] = true
+
+		if name != column.Name {
+			changes.Renamed = append(changes.Renamed, &ColumnRename{From: column.Name, To: name})
+		}
+
+		if renames.table(column.References) != nextColumn.References || column.Unique != nextColumn.Unique || column.JSON != nextColumn.JSON {
+			removed := *column
+			removed.Name = name
+			changes.Removed = append(changes.Removed, &removed)
+			changes.Added = append(changes.Added, nextColumn)
+			continue
+		}
+
+		if column.Type != nextColumn.Type {
+			changes.Retyped = append(changes.Retyped, &ColumnType{Column: nextColumn, From: column.Type})
+		}
+	}
+
+	for _, column := range next.Columns {
+		if !matched[column.Name] {
+			changes.Added = append(changes.Added, column)
+		}
+	}
+
+	previousIndexes := make(map[string]bool)
+	nextIndexes := make(map[string]bool)
+
+	for _, index := range previous.Indexes {
+		previousIndexes[indexSignature(index)] = true
+	}
+
+	for _, index := range next.Indexes {
+		nextIndexes[indexSignature(index)] = true
+
+		if !previousIndexes[indexSignature(index)] {
+			changes.AddedIndexes = append(changes.AddedIndexes, index)
+		}
+	}
+
+	for _, index := range previous.Indexes {
+		if !nextIndexes[indexSignature(index)] {
+			changes.RemovedIndexes = append(changes.RemovedIndexes, index)
+		}
+	}
+
+	return changes
+}
+
+func indexSignature(index *Index) string {
+	signature := fmt.Sprintf("%s %v", index.Name, index.Unique)
+
+	for _, column := range index.Columns {
+		signature += fmt.Sprintf(" %s:%v", column.Name, column.Desc)
+	}
+
+	return signature
+}
@@ -0,0 +1,219 @@
+package common
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Dialect - What the tables and migrations of a SQL database depend on
+type Dialect struct {
+	// Column types by field type. Besides the field types it maps "id", "time"
+	// and "json", the type of the nested entities.
+	Types map[string]string
+	// Whether a migration can change the type of a column. SQLite can not, but
+	// it keeps the values of a column whatever its declared type is.
+	AlterTypes bool
+}
+
+// Column - A column of the table of an entity
+type Column struct {
+	Name       string // Name of the column, the JSON name of the field, e.g. "userId"
+	Field      string // Field of the entity struct, e.g. "UserID"
+	Type       string // Type of the column in the database
+	JSON       bool   // Nested entities, encoded as JSON
+	References string // Table referenced by a foreign key
+	Unique     bool   // Foreign key of a hasOne relationship, a row has one child at most
+	Default    string // Value of the existing rows when a migration adds the column, empty for the foreign keys
+}
+
+// Index - An index of the table of an entity
+type Index struct {
+	Name    string // e.g. "users_email_idx"
+	Unique  bool
+	Columns []*IndexColumn
+}
+
+// IndexColumn - A column of an index
+type IndexColumn struct {
+	Name string
+	Desc bool // Sorted in descending order
+}
+
+// Table - The table of an entity persisted in a SQL database
+type Table struct {
+	Name    string
+	Entity  *entities.Entity
+	Columns []*Column
+	Indexes []*Index
+}
+
+// Tables - Returns the tables of the entities with a repository, in the order
+// they are created: a table comes after the tables it references
+func (d Dialect) Tables(definitions *entities.Definitions) []*Table {
+	pending := make([]*entities.Entity, 0)
+
+	for _, entity := range definitions.App.Entities {
+		if entity.HasRepository() {
+			pending = append(pending, entity)
+		}
+	}
+
+	result := make([]*Table, 0, len(pending))
+	created := make(map[string]bool)
+
+	for len(pending) > 0 {
+		next := make([]*entities.Entity, 0)
+
+		for _, entity := range pending {
+			if !d.referencesCreated(entity, created) {
+				next = append(next, entity)
+				continue
+			}
+
+			created[entity.Name] = true
+			result = append(result, d.table(entity))
+		}
+
+		// Tables referencing each other are created in declaration order
+		if len(next) == len(pending) {
+			created[next[0].Name] = true
+			result = append(result, d.table(next[0]))
+			next = next[1:]
+		}
+
+		pending = next
+	}
+
+	return result
+}
+
+// Table - Returns the table of an entity, nil when it is not persisted
+func (d Dialect) Table(entity *entities.Entity) *Table {
+	for _, table := range d.Tables(entity.Definitions) {
+		if table.Entity.Name == entity.Name {
+			return table
+		}
+	}
+	return nil
+}
+
+// Column - Returns the column with the given name, nil when there is none
+func (t *Table) Column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+func (d Dialect) referencesCreated(entity *entities.Entity, created map[string]bool) bool {
+	for _, parent := range entity.BelongsTo() {
+		if parent.HasRepository() && parent.Name != entity.Name && !created[parent.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d Dialect) table(entity *entities.Entity) *Table {
+	columns := []*Column{{Name: "id", Field: "ID", Type: d.Types["id"], Default: "''"}}
+
+	for _, field := range entity.Fields {
+		columns = append(columns, &Column{Name: field.Name, Field: templates.Capitalize(field.Name), Type: d.Types[field.Type], Default: zeroLiteral(field.Type)})
+	}
+
+	for _, child := range entity.HasMany() {
+		if child.IsNestedIn(entity) {
+			columns = append(columns, &Column{Name: templates.Pluralize(child.Name), Field: templates.Pluralize(templates.Capitalize(child.Name)), Type: d.Types["json"], JSON: true, Default: "'null'"})
+		}
+	}
+
+	for _, child := range entity.HasOne() {
+		if child.IsNestedIn(entity) {
+			columns = append(columns, &Column{Name: child.Name, Field: templates.Capitalize(child.Name), Type: d.Types["json"], JSON: true, Default: "'null'"})
+		}
+	}
+
+	for _, parent := range entity.BelongsTo() {
+		column := &Column{Name: parent.Name + "Id", Field: templates.Capitalize(parent.Name) + "ID", Type: d.Types["id"], Default: "''"}
+
+		if parent.HasRepository() {
+			column.References = templates.Pluralize(parent.Name)
+			column.Unique = hasOne(entity.Definitions, parent, entity)
+			column.Default = ""
+		}
+
+		columns = append(columns, column)
+	}
+
+	// SQLite only adds columns with a constant default, so the existing rows
+	// get the epoch instead of the time of the migration
+	if entity.Timestamps {
+		columns = append(columns,
+			&Column{Name: "createdAt", Field: "CreatedAt", Type: d.Types["time"], Default: "'1970-01-01 00:00:00'"},
+			&Column{Name: "updatedAt", Field: "UpdatedAt", Type: d.Types["time"], Default: "'1970-01-01 00:00:00'"},
+		)
+	}
+
+	name := templates.Pluralize(entity.Name)
+	indexes := make([]*Index, 0, len(entity.Indexes))
+
+	for _, entityIndex := range entity.Indexes {
+		index := &Index{Name: name, Unique: entityIndex.Unique}
+
+		for _, field := range entityIndex.Fields {
+			index.Name += "_" + field.Name
+			index.Columns = append(index.Columns, &IndexColumn{Name: field.Name, Desc: field.Sort == "desc"})
+		}
+
+		index.Name += "_idx"
+		indexes = append(indexes, index)
+	}
+
+	return &Table{
+		Name:    name,
+		Entity:  entity,
+		Columns: columns,
+		Indexes: indexes,
+	}
+}
+
+func hasOne(definitions *entities.Definitions, parent *entities.Entity, child *entities.Entity) bool {
+	for _, relationship := range definitions.App.Relationships {
+		if relationship.Item1 == parent.Name && relationship.Item2 == child.Name && relationship.IsTypeHasOne() {
+			return true
+		}
+	}
+	return false
+}
+
+// FuncMap - Functions of the templates of the databases with tables
+func (d Dialect) FuncMap() map[string]interface{} {
+	return map[string]interface{}{
+		"table": d.Table,
+		"zero":  zero,
+	}
+}
+
+// Zero value of a field type, used to skip the filters not set
+func zero(fieldType string) string {
+	switch fieldType {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	}
+	return "0"
+}
+
+// SQL literal of the zero value of a field type
+func zeroLiteral(fieldType string) string {
+	switch fieldType {
+	case "string":
+		return "''"
+	case "bool":
+		return "FALSE"
+	}
+	return "0"
+}
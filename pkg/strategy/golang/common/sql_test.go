@@ -0,0 +1,239 @@
+package common
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+)
+
+const shopDefinition = `
+version: 1.0.0
+app:
+  name: shop
+  repository: github.com/example/shop
+  stack: { language: go, database: postgres }
+  entities:
+    - name: sale
+      fields:
+        - { name: total, type: float64 }
+      persisted: true
+      timestamps: true
+    - name: payment
+      fields:
+        - { name: method, type: string }
+      persisted: true
+    - name: customer
+      fields:
+        - { name: email, type: string }
+        - { name: active, type: bool }
+      indexes:
+        - fields: [{ name: email }]
+          unique: true
+      persisted: true
+    - name: item
+      fields:
+        - { name: quantity, type: int }
+      persisted: true
+  relationships:
+    - { item1: customer, item2: sale, type: hasMany }
+    - { item1: sale, item2: payment, type: hasOne }
+    - { item1: sale, item2: item, type: hasMany, nested: true }
+`
+
+var testDialect = Dialect{
+	Types: map[string]string{
+		"id":      "TEXT",
+		"string":  "TEXT",
+		"bool":    "BOOLEAN",
+		"int":     "BIGINT",
+		"float64": "DOUBLE PRECISION",
+		"time":    "TIMESTAMPTZ",
+		"json":    "JSONB",
+	},
+	AlterTypes: true,
+}
+
+func readShop(t *testing.T) *entities.Definitions {
+	var definitions entities.Definitions
+
+	if _, err := reader.NewReader().Read([]byte(shopDefinition), &definitions); err != nil {
+		t.Fatalf("reading definitions: %s", err)
+	}
+
+	return &definitions
+}
+
+func TestDialectTables(t *testing.T) {
+	tables := testDialect.Tables(readShop(t))
+	names := make([]string, 0, len(tables))
+
+	for _, table := range tables {
+		columns := make([]string, 0, len(table.Columns))
+
+		for _, column := range table.Columns {
+			description := column.Name + " " + column.Type
+
+			if column.References != "" {
+				description += " -> " + column.References
+			}
+
+			if column.Unique {
+				description += " unique"
+			}
+
+			if column.JSON {
+				description += " json"
+			}
+
+			columns = append(columns, description)
+		}
+
+		names = append(names, table.Name+"("+strings.Join(columns, ", ")+")")
+	}
+
+	expected := []string{
+		"customers(id TEXT, email TEXT, active BOOLEAN)",
+		"sales(id TEXT, total DOUBLE PRECISION, items JSONB json, customerId TEXT -> customers, createdAt TIMESTAMPTZ, updatedAt TIMESTAMPTZ)",
+		"payments(id TEXT, method TEXT, saleId TEXT -> sales unique)",
+	}
+
+	if strings.Join(names, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected tables:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(names, "\n"))
+	}
+}
+
+func TestDialectMigrations(t *testing.T) {
+	fileMap, err := testDialect.Migrations(readShop(t), noPrevious)
+
+	if err != nil {
+		t.Fatalf("Migrations returned an error: %s", err)
+	}
+
+	paths := make(map[string]string)
+
+	for key, file := range fileMap {
+		paths[key] = file.FinalPath
+	}
+
+	expected := map[string]string{
+		"migrations":                           "migrations/migrations.go",
+		"migrations_schema":                    "migrations/schema.json",
+		"migration_0001_create_customers_up":   "migrations/0001_create_customers.up.sql",
+		"migration_0001_create_customers_down": "migrations/0001_create_customers.down.sql",
+		"migration_0002_create_sales_up":       "migrations/0002_create_sales.up.sql",
+		"migration_0002_create_sales_down":     "migrations/0002_create_sales.down.sql",
+		"migration_0003_create_payments_up":    "migrations/0003_create_payments.up.sql",
+		"migration_0003_create_payments_down":  "migrations/0003_create_payments.down.sql",
+	}
+
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %d migration files, got %v", len(expected), paths)
+	}
+
+	for key, path := range expected {
+		if paths[key] != path {
+			t.Errorf("expected %s to be written to %s, got %q", key, path, paths[key])
+		}
+	}
+}
+
+// previousBuild reads the files of a build, the rendered content being the
+// name of the file, as the migrations of the last build are read back as is
+func previousBuild(fileMap map[string]*entities.File) Previous {
+	return func(name string) ([]byte, error) {
+		for _, file := range fileMap {
+			if file.FinalPath != name {
+				continue
+			}
+
+			if file.TemplatePath == "" {
+				return []byte(file.Result), nil
+			}
+
+			return []byte("-- " + name), nil
+		}
+
+		return nil, fmt.Errorf("reading %s: %w", name, fs.ErrNotExist)
+	}
+}
+
+func TestDialectMigrationsChanges(t *testing.T) {
+	first, err := testDialect.Migrations(readShop(t), noPrevious)
+
+	if err != nil {
+		t.Fatalf("Migrations returned an error: %s", err)
+	}
+
+	data := shopDefinition
+	for _, replacement := range [][2]string{
+		{"{ name: method, type: string }", "{ name: kind, type: string }"},
+		{"{ name: active, type: bool }", "{ name: active, type: int }\n        - { name: phone, type: string }"},
+		{"  relationships:\n", "    - name: refund\n      fields: [{ name: amount, type: float64 }]\n      persisted: true\n  relationships:\n    - { item1: payment, item2: refund, type: hasMany }\n"},
+	} {
+		data = strings.Replace(data, replacement[0], replacement[1], 1)
+	}
+
+	var next entities.Definitions
+
+	if _, err := reader.NewReader().Read([]byte(data), &next); err != nil {
+		t.Fatalf("reading definitions: %s", err)
+	}
+
+	second, err := testDialect.Migrations(&next, previousBuild(first))
+
+	if err != nil {
+		t.Fatalf("Migrations returned an error: %s", err)
+	}
+
+	if kept := second["migration_0001_create_customers_up"]; kept == nil || kept.TemplatePath != "" || kept.Result != "-- migrations/0001_create_customers.up.sql" {
+		t.Fatalf("expected the migrations of the last build to be kept as they were, got %+v", kept)
+	}
+
+	added := make([]string, 0)
+
+	for key, file := range second {
+		if file.TemplatePath != "" && strings.HasSuffix(key, "_up") {
+			added = append(added, file.FinalPath)
+		}
+	}
+
+	sort.Strings(added)
+	expected := []string{
+		"migrations/0004_create_refunds.up.sql",
+		"migrations/0005_alter_customers.up.sql",
+		"migrations/0006_alter_payments.up.sql",
+	}
+
+	if strings.Join(added, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected the new migrations %v, got %v", expected, added)
+	}
+
+	customers := second["migration_0005_alter_customers_up"].Data.(*TableChanges)
+
+	if len(customers.Added) != 1 || customers.Added[0].Name != "phone" || len(customers.Retyped) != 1 || customers.Retyped[0].From != "BOOLEAN" {
+		t.Errorf("expected phone to be added and active to be retyped, got %+v", customers)
+	}
+
+	payments := second["migration_0006_alter_payments_up"].Data.(*TableChanges)
+
+	if len(payments.Renamed) != 1 || *payments.Renamed[0] != (ColumnRename{From: "method", To: "kind"}) || len(payments.Removed) != 0 {
+		t.Errorf("expected method to be renamed to kind, got %+v", payments)
+	}
+
+	third, err := testDialect.Migrations(&next, previousBuild(second))
+
+	if err != nil {
+		t.Fatalf("Migrations returned an error: %s", err)
+	}
+
+	for key, file := range third {
+		if file.TemplatePath != "" && key != "migrations" {
+			t.Errorf("expected no new migration without changes, got %s", file.FinalPath)
+		}
+	}
+}
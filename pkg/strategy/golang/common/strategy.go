@@ -0,0 +1,451 @@
+// Package common holds the strategy of the Go stacks. The stacks share most of
+// their templates, the database and the web framework provide the rest.
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/danilo-medeiros/fancybuild/engine/internal/pipeline"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/render"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Names of the post build steps
+const (
+	StepFormat = "format" // Formats again the Go files on disk, e.g. the merged ones. It is not a default step.
+	StepMod    = "mod"    // Creates the module with go mod init, when there is no go.mod
+	StepTidy   = "tidy"   // Resolves the dependencies with go mod tidy, it needs network access
+	StepBuild  = "build"  // Builds the project
+	StepTest   = "test"   // Runs the tests of the project, they may need the services of docker-compose.yml
+)
+
+// Database - What a Go stack takes from its database
+type Database struct {
+	Name    string           // Folder of the templates of the database, e.g. "mongodb"
+	Folders []string         // Folders searched after the one of the database, e.g. "sql"
+	FuncMap template.FuncMap // Functions available to the templates, besides the default ones
+
+	// Files only the database has, e.g. migrations. It may be nil.
+	Files func(*entities.Definitions, Previous) (map[string]*entities.File, error)
+}
+
+// Previous - Reads the content a file of the project had when it was last
+// generated. It fails with an error wrapping fs.ErrNotExist for new files.
+type Previous func(name string) ([]byte, error)
+
+// Reads nothing, the previous files of a strategy the builder did not give any
+func noPrevious(name string) ([]byte, error) {
+	return nil, fmt.Errorf("on reading the previous %s: %w", name, fs.ErrNotExist)
+}
+
+type strategy struct {
+	*entities.Definitions
+	FileMap   map[string]*entities.File
+	Templates *templates.Overlay
+	cache     *templates.Cache // Templates of the files
+	jsonCache *templates.Cache // Templates used by jsonMarshal
+	seed      int64            // Seed of the example values
+	options   *entities.Options
+	database  *Database
+	framework *Framework
+	previous  Previous
+	conflicts map[string]bool // Files changed by the user that the format step leaves alone, by path
+	rendered  func(*entities.File)
+}
+
+// SetPrevious - Reads the files of the last build, the migrations depend on them
+func (s *strategy) SetPrevious(read func(name string) ([]byte, error)) {
+	s.previous = read
+}
+
+// SetOnRendered - Sets the function called with every file once it is rendered
+func (s *strategy) SetOnRendered(rendered func(file *entities.File)) {
+	s.rendered = rendered
+}
+
+// SetConflicts - Gives the files changed by the user that were not overwritten
+// or merged with conflicts, the format step leaves them as they are
+func (s *strategy) SetConflicts(paths []string) {
+	s.conflicts = make(map[string]bool)
+
+	for _, path := range paths {
+		s.conflicts[path] = true
+	}
+}
+
+// Path of the template of a file, looked up in the folders of the database
+// and then of the framework, before the shared Go templates
+func (s *strategy) template(name string) string {
+	database := append([]string{s.database.Name}, s.database.Folders...)
+	framework := append([]string{s.framework.Name}, s.framework.Folders...)
+	return s.Templates.Lookup("go", name, database, framework)
+}
+
+// Tidies the rendered files and formats the Go ones with go/format, so the
+// files are written, hashed and merged as they are formatted
+func tidy(finalPath string, text string) (string, error) {
+	text = templates.SimpleFormat(text)
+
+	if !strings.HasSuffix(finalPath, ".go") {
+		return text, nil
+	}
+
+	formatted, err := format.Source([]byte(text))
+
+	if err != nil {
+		return "", fmt.Errorf("on formatting %s: %v", finalPath, err)
+	}
+
+	return string(formatted), nil
+}
+
+func (s *strategy) BuildFileMap() (map[string]*entities.File, error) {
+	fileMap := map[string]*entities.File{
+		"main": {
+			FinalPath:    "main.go",
+			TemplatePath: s.template("main"),
+		},
+		"app": {
+			FinalPath:    "pkg/app/app.go",
+			TemplatePath: s.template("app"),
+		},
+		"validator": {
+			FinalPath:    "pkg/validator/validator.go",
+			TemplatePath: s.template("validator"),
+		},
+		"router": {
+			FinalPath:    "pkg/router/router.go",
+			TemplatePath: s.template("router"),
+		},
+		"entities": {
+			FinalPath:    "pkg/entities/entities.go",
+			TemplatePath: s.template("entities"),
+		},
+		"health": {
+			FinalPath:    "pkg/health/controller.go",
+			TemplatePath: s.template("health"),
+		},
+		"database": {
+			FinalPath:    "pkg/database/database.go",
+			TemplatePath: s.template("database"),
+		},
+		"error_handler": {
+			FinalPath:    "pkg/errors/handler.go",
+			TemplatePath: s.template("error_handler"),
+		},
+		"gitignore": {
+			FinalPath:    ".gitignore",
+			TemplatePath: s.template("gitignore"),
+		},
+		"env": {
+			FinalPath:    ".env",
+			TemplatePath: s.template("env"),
+		},
+		"env_test": {
+			FinalPath:    ".env.test",
+			TemplatePath: s.template("env_test"),
+		},
+		"readme": {
+			FinalPath:    "README.md",
+			TemplatePath: s.template("readme"),
+		},
+		"main_test": {
+			FinalPath:    "test/main_test.go",
+			TemplatePath: s.template("main_test"),
+		},
+		"test_utils": {
+			FinalPath:    "test/utils/utils.go",
+			TemplatePath: s.template("test_utils"),
+		},
+		"dockerfile": {
+			FinalPath:    "Dockerfile",
+			TemplatePath: s.template("dockerfile"),
+		},
+		"docker-compose": {
+			FinalPath:    "docker-compose.yml",
+			TemplatePath: s.template("docker-compose"),
+		},
+	}
+
+	if s.Definitions.HasAuthentication() {
+		fileMap["auth_controller"] = &entities.File{
+			FinalPath:    "pkg/auth/controller.go",
+			TemplatePath: s.template("auth_controller"),
+		}
+		fileMap["auth_handler"] = &entities.File{
+			FinalPath:    "pkg/auth/handler.go",
+			TemplatePath: s.template("auth_handler"),
+		}
+		fileMap["auth_service"] = &entities.File{
+			FinalPath:    "pkg/auth/service.go",
+			TemplatePath: s.template("auth_service"),
+		}
+		fileMap["auth_store"] = &entities.File{
+			FinalPath:    "pkg/auth/store.go",
+			TemplatePath: s.template("auth_store"),
+		}
+		fileMap["auth_test"] = &entities.File{
+			FinalPath:    "test/auth/auth_test.go",
+			TemplatePath: s.template("auth_test"),
+		}
+	}
+
+	// Dependencies pinned to versions known to work, so they are not resolved over the network
+	if s.options.Offline {
+		fileMap["go_mod"] = &entities.File{
+			FinalPath:    "go.mod",
+			TemplatePath: s.template("go_mod"),
+		}
+		fileMap["go_sum"] = &entities.File{
+			FinalPath:    "go.sum",
+			TemplatePath: s.template("go_sum"),
+		}
+	}
+
+	for _, file := range fileMap {
+		file.Data = &s.Definitions
+	}
+
+	if s.database.Files != nil {
+		databaseFiles, err := s.database.Files(s.Definitions, s.previous)
+
+		if err != nil {
+			return nil, fmt.Errorf("error building the database files: %v", err)
+		}
+
+		for key, file := range databaseFiles {
+			fileMap[key] = file
+		}
+	}
+
+	if s.framework.Files != nil {
+		for key, file := range s.framework.Files(s.Definitions) {
+			fileMap[key] = file
+		}
+	}
+
+	for _, entity := range s.Definitions.App.Entities {
+		var data struct {
+			*entities.Definitions
+			*entities.Entity
+		}
+
+		data.Definitions = s.Definitions
+		data.Entity = entity
+
+		if entity.HasController() {
+			fileMap[fmt.Sprintf("%s_controller", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("pkg/%s/controller.go", entity.Name),
+				TemplatePath: s.template("controller"),
+				Data:         data,
+			}
+
+			// TODO: remove this later, currently we only have test coverage for create action
+			if entity.HasAction("create") {
+				fileMap[fmt.Sprintf("%s_controller_test", entity.Name)] = &entities.File{
+					FinalPath:    fmt.Sprintf("test/%s/controller_test.go", entity.Name),
+					TemplatePath: s.template("controller_test"),
+					Data:         data,
+				}
+			}
+		}
+
+		if entity.HasService() {
+			fileMap[fmt.Sprintf("%s_service", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("pkg/%s/service.go", entity.Name),
+				TemplatePath: s.template("service"),
+				Data:         data,
+			}
+		}
+
+		if entity.HasRepository() {
+			fileMap[fmt.Sprintf("%s_repository", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("pkg/%s/repository.go", entity.Name),
+				TemplatePath: s.template("repository"),
+				Data:         data,
+			}
+		}
+
+		fileMap[fmt.Sprintf("%s_entity", entity.Name)] = &entities.File{
+			FinalPath:    fmt.Sprintf("pkg/entities/%s.go", entity.Name),
+			TemplatePath: s.template("entity"),
+			Data:         data,
+		}
+	}
+
+	err := render.ManifestFiles(s.Templates, s.Definitions, fileMap)
+
+	if err != nil {
+		return nil, fmt.Errorf("error adding manifest files: %v", err)
+	}
+
+	err = render.Files(s.cache, fileMap, tidy, s.rendered)
+
+	if err != nil {
+		return nil, fmt.Errorf("error rendering file map: %v", err)
+	}
+
+	s.FileMap = fileMap
+
+	return fileMap, nil
+}
+
+func (s *strategy) BuildPostActions(projectPath string) error {
+	steps, err := s.PostSteps()
+
+	if err != nil {
+		return err
+	}
+
+	return pipeline.Run(steps, projectPath)
+}
+
+func (s *strategy) PostSteps() ([]*entities.Step, error) {
+	// The files are formatted as they are rendered, the format step is only a re-check
+	defaults := []string{StepMod, StepTidy, StepBuild, StepTest}
+
+	// The go.mod and go.sum files are generated, with pinned versions
+	if s.options.Offline {
+		defaults = []string{StepBuild, StepTest}
+	}
+
+	return pipeline.Select(s.steps(), defaults, s.options)
+}
+
+// All the post build steps the strategy can run
+func (s *strategy) steps() []*entities.Step {
+	var env []string
+
+	// Dependencies are only read from the module cache
+	if s.options.Offline {
+		env = []string{"GOPROXY=off"}
+	}
+
+	return []*entities.Step{
+		{Name: StepFormat, Run: s.format},
+		{Name: StepMod, Run: func(projectPath string, output io.Writer) error {
+			// The module already exists when the project is regenerated
+			if _, err := os.Stat(filepath.Join(projectPath, "go.mod")); !os.IsNotExist(err) {
+				return nil
+			}
+
+			return pipeline.Command(projectPath, env, output, "go", "mod", "init", s.App.Repository)
+		}},
+		{Name: StepTidy, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, env, output, "go", "mod", "tidy")
+		}},
+		{Name: StepBuild, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, env, output, "go", "build")
+		}},
+		{Name: StepTest, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, env, output, "go", "test", "./...")
+		}},
+	}
+}
+
+// Formats the Go files of the project on disk with go/format, they differ
+// from the rendered ones when the user changed them. The files with conflicts
+// are skipped, they may not even parse.
+func (s *strategy) format(projectPath string, output io.Writer) error {
+	paths := make([]string, 0, len(s.FileMap))
+
+	for _, file := range s.FileMap {
+		if strings.HasSuffix(file.FinalPath, ".go") && !s.conflicts[file.FinalPath] {
+			paths = append(paths, filepath.Join(projectPath, filepath.FromSlash(file.FinalPath)))
+		}
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		formatted, err := format.Source(content)
+
+		if err != nil {
+			return fmt.Errorf("on formatting %s: %v", path, err)
+		}
+
+		if bytes.Equal(content, formatted) {
+			continue
+		}
+
+		fmt.Fprintf(output, "formatted %s\n", path)
+
+		err = os.WriteFile(path, formatted, 0644)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewStrategy - Creates the strategy of a Go stack using the given database and
+// the framework of the definitions, the default one when they have none
+func NewStrategy(definitions *entities.Definitions, options *entities.Options, database *Database) (entities.Strategy, error) {
+	if options == nil {
+		options = &entities.Options{}
+	}
+
+	name := definitions.App.Stack.Framework
+
+	if name == "" {
+		name = DefaultFramework
+	}
+
+	framework, ok := Frameworks[name]
+
+	if !ok {
+		names := make([]string, 0, len(Frameworks))
+
+		for name := range Frameworks {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown Go framework %q, the frameworks are: %s", name, strings.Join(names, ", "))
+	}
+
+	s := &strategy{
+		Definitions: definitions,
+		Templates:   templates.NewOverlay(options.TemplateDirs...),
+		seed:        options.Seed,
+		options:     options,
+		database:    database,
+		framework:   framework,
+		previous:    noPrevious,
+	}
+
+	funcMap := templates.DefaultFuncMap()
+	funcMap["buildValidations"] = buildValidations
+	funcMap["jsonMarshal"] = s.jsonMarshal
+	funcMap["framework"] = func() string { return framework.Name }
+
+	for name, fn := range database.FuncMap {
+		funcMap[name] = fn
+	}
+
+	s.cache = templates.NewCache(s.Templates, funcMap)
+
+	jsonFuncMap := templates.DefaultFuncMap()
+	jsonFuncMap["jsonMarshalField"] = s.jsonMarshalField
+	s.jsonCache = templates.NewCache(s.Templates, jsonFuncMap)
+
+	return s, nil
+}
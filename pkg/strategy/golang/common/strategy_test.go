@@ -0,0 +1,557 @@
+package common_test
+
+import (
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/memory"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/mongodb"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/postgres"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/sqlite"
+)
+
+// syntheticDefinitions builds an app with the given number of entities, all of
+// them persisted and exposing every action
+func syntheticDefinitions(t testing.TB, size int) *entities.Definitions {
+	items := make([]string, 0, size)
+
+	for i := 0; i < size; i++ {
+		items = append(items, fmt.Sprintf(`{
+			"name": "entity%d",
+			"fields": [
+				{"name": "title", "type": "string", "validations": [{"name": "required", "value": "true"}, {"name": "min", "value": "3"}, {"name": "max", "value": "40"}]},
+				{"name": "count", "type": "int", "validations": [{"name": "min", "value": "1"}, {"name": "max", "value": "100"}]},
+				{"name": "done", "type": "bool"}
+			],
+			"actions": [{"type": "create"}, {"type": "update"}, {"type": "delete"}, {"type": "getOne"}, {"type": "getAll"}],
+			"indexes": [{"fields": [{"name": "title"}]}],
+			"timestamps": true,
+			"persisted": true
+		}`, i))
+	}
+
+	data := fmt.Sprintf(`{
+		"version": "1.0.0",
+		"app": {
+			"name": "synthetic",
+			"repository": "github.com/example/synthetic",
+			"stack": {"language": "go", "database": "mongodb"},
+			"entities": [%s]
+		}
+	}`, strings.Join(items, ","))
+
+	var definitions entities.Definitions
+
+	if _, err := reader.NewReader().Read([]byte(data), &definitions); err != nil {
+		t.Fatalf("reading synthetic definitions: %s", err)
+	}
+
+	definitions.Id = "1"
+	return &definitions
+}
+
+// newStrategy creates a strategy that must accept the definitions
+func newStrategy(t testing.TB, factory func(*entities.Definitions, *entities.Options) (entities.Strategy, error), definitions *entities.Definitions, options *entities.Options) entities.Strategy {
+	stgy, err := factory(definitions, options)
+
+	if err != nil {
+		t.Fatalf("creating the strategy: %s", err)
+	}
+
+	return stgy
+}
+
+func TestBuildFileMap(t *testing.T) {
+	fileMap, err := newStrategy(t, mongodb.NewStrategy, syntheticDefinitions(t, 3), nil).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	for _, key := range []string{"main", "entity0_controller", "entity1_service", "entity2_repository"} {
+		file, ok := fileMap[key]
+
+		if !ok {
+			t.Fatalf("file %s was not generated", key)
+		}
+
+		if file.Result == "" {
+			t.Errorf("file %s was not rendered", key)
+		}
+	}
+
+	// The Go files are generated formatted, as they are written and hashed
+	for key, file := range fileMap {
+		if !strings.HasSuffix(file.FinalPath, ".go") {
+			continue
+		}
+
+		formatted, err := format.Source([]byte(file.Result))
+
+		if err != nil || string(formatted) != file.Result {
+			t.Errorf("file %s was not formatted: %v", key, err)
+		}
+	}
+}
+
+func TestBuildFileMapFormatError(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "go/fiber/main.tmpl"), "package main\nfunc main() {\n")
+	_, err := newStrategy(t, mongodb.NewStrategy, syntheticDefinitions(t, 1), &entities.Options{TemplateDirs: []string{dir}}).BuildFileMap()
+
+	if err == nil || !strings.Contains(err.Error(), "on formatting main.go") {
+		t.Errorf("expected the error of formatting main.go, got %v", err)
+	}
+}
+
+func TestBuildFileMapReproducible(t *testing.T) {
+	definitions := syntheticDefinitions(t, 5)
+	render := func(seed int64) map[string]*entities.File {
+		fileMap, err := newStrategy(t, mongodb.NewStrategy, definitions, &entities.Options{Seed: seed}).BuildFileMap()
+
+		if err != nil {
+			t.Fatalf("BuildFileMap returned an error: %s", err)
+		}
+
+		return fileMap
+	}
+
+	first := render(0)
+	second := render(0)
+
+	for key, file := range first {
+		if second[key].Result != file.Result {
+			t.Errorf("file %s changed between two builds of the same definitions", key)
+		}
+	}
+
+	if render(7)["entity0_controller_test"].Result == first["entity0_controller_test"].Result {
+		t.Errorf("the example values of the tests should depend on the seed")
+	}
+}
+
+func TestBuildFileMapOffline(t *testing.T) {
+	fileMap, err := newStrategy(t, mongodb.NewStrategy, syntheticDefinitions(t, 1), &entities.Options{Offline: true}).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	if !strings.HasPrefix(fileMap["go_mod"].Result, "module github.com/example/synthetic\n") {
+		t.Errorf("go.mod should declare the module of the app, got:\n%s", fileMap["go_mod"].Result)
+	}
+
+	if !strings.Contains(fileMap["go_sum"].Result, "github.com/gofiber/fiber/v2") {
+		t.Errorf("go.sum should pin the dependencies")
+	}
+}
+
+func TestBuildFileMapPostgres(t *testing.T) {
+	fileMap, err := newStrategy(t, postgres.NewStrategy, syntheticDefinitions(t, 2), &entities.Options{Offline: true}).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	up := fileMap["migration_0002_create_entity1s_up"]
+
+	if up == nil || up.FinalPath != "migrations/0002_create_entity1s.up.sql" {
+		t.Fatalf("expected a migration creating the table of entity1, got %+v", up)
+	}
+
+	for _, statement := range []string{`CREATE TABLE "entity1s" (`, `"count" BIGINT NOT NULL,`, `CREATE INDEX "entity1s_title_idx" ON "entity1s" ("title");`} {
+		if !strings.Contains(up.Result, statement) {
+			t.Errorf("expected the migration to contain %s, got:\n%s", statement, up.Result)
+		}
+	}
+
+	if !strings.Contains(fileMap["go_mod"].Result, "github.com/jackc/pgx/v5") {
+		t.Errorf("go.mod should require the PostgreSQL driver")
+	}
+
+	if strings.Contains(fileMap["entity0_repository"].Result, "mongo") {
+		t.Errorf("the repository should not use MongoDB")
+	}
+}
+
+func TestBuildFileMapSQLite(t *testing.T) {
+	fileMap, err := newStrategy(t, sqlite.NewStrategy, syntheticDefinitions(t, 1), &entities.Options{Offline: true}).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	if up := fileMap["migration_0001_create_entity0s_up"].Result; !strings.Contains(up, `"done" BOOLEAN NOT NULL,`) || !strings.Contains(up, `"createdAt" DATETIME NOT NULL,`) {
+		t.Errorf("expected the migration to use the SQLite types, got:\n%s", up)
+	}
+
+	if !strings.Contains(fileMap["database"].Result, `"modernc.org/sqlite"`) {
+		t.Errorf("the database should use the pure Go driver")
+	}
+
+	if strings.Contains(fileMap["go_mod"].Result, "redis") {
+		t.Errorf("the project should not depend on Redis")
+	}
+}
+
+// rebuild builds the file map of a strategy reading the files of the last build from fileMap
+func rebuild(t *testing.T, stgy entities.Strategy, fileMap map[string]*entities.File) map[string]*entities.File {
+	stgy.(entities.IncrementalStrategy).SetPrevious(func(name string) ([]byte, error) {
+		for _, file := range fileMap {
+			if file.FinalPath == name {
+				return []byte(file.Result), nil
+			}
+		}
+
+		return nil, fmt.Errorf("reading %s: %w", name, fs.ErrNotExist)
+	})
+
+	result, err := stgy.BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	return result
+}
+
+func TestBuildFileMapMigrationChanges(t *testing.T) {
+	testCases := []struct {
+		Description string
+		NewStrategy func(*entities.Definitions, *entities.Options) (entities.Strategy, error)
+		Expected    []string
+	}{
+		{
+			Description: "postgres",
+			NewStrategy: postgres.NewStrategy,
+			Expected: []string{
+				`DROP INDEX IF EXISTS "entity0s_title_idx";`,
+				`ALTER TABLE "entity0s" RENAME COLUMN "title" TO "name";`,
+				`ALTER TABLE "entity0s" ADD COLUMN "note" TEXT NOT NULL DEFAULT '';`,
+				`ALTER TABLE "entity0s" ALTER COLUMN "count" TYPE DOUBLE PRECISION USING "count"::DOUBLE PRECISION;`,
+				`CREATE INDEX "entity0s_name_idx" ON "entity0s" ("name");`,
+			},
+		},
+		{
+			Description: "sqlite",
+			NewStrategy: sqlite.NewStrategy,
+			Expected: []string{
+				`ALTER TABLE "entity0s" RENAME COLUMN "title" TO "name";`,
+				`ALTER TABLE "entity0s" ADD COLUMN "note" TEXT NOT NULL DEFAULT '';`,
+				`-- "count" keeps its declared type INTEGER, the database can not change it to REAL`,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Description, func(t *testing.T) {
+			definitions := syntheticDefinitions(t, 1)
+			first, err := newStrategy(t, testCase.NewStrategy, definitions, nil).BuildFileMap()
+
+			if err != nil {
+				t.Fatalf("BuildFileMap returned an error: %s", err)
+			}
+
+			entity := definitions.App.Entities[0]
+			entity.Fields[0].Name = "name"
+			entity.Fields[1].Type = "float64"
+			entity.Fields = append(entity.Fields, &entities.Field{Name: "note", Type: "string"})
+			entity.Indexes[0].Fields[0].Name = "name"
+
+			second := rebuild(t, newStrategy(t, testCase.NewStrategy, definitions, nil), first)
+
+			if second["migration_0001_create_entity0s_up"].Result != first["migration_0001_create_entity0s_up"].Result {
+				t.Errorf("expected the applied migration to be kept as it was")
+			}
+
+			up := second["migration_0002_alter_entity0s_up"]
+
+			if up == nil {
+				t.Fatalf("expected a migration altering the table")
+			}
+
+			for _, statement := range testCase.Expected {
+				if !strings.Contains(up.Result, statement) {
+					t.Errorf("expected the migration to contain %s, got:\n%s", statement, up.Result)
+				}
+			}
+
+			down := second["migration_0002_alter_entity0s_down"].Result
+
+			if !strings.Contains(down, `ALTER TABLE "entity0s" RENAME COLUMN "name" TO "title";`) || !strings.Contains(down, `ALTER TABLE "entity0s" DROP COLUMN "note";`) {
+				t.Errorf("expected the down migration to revert the changes, got:\n%s", down)
+			}
+
+			third := rebuild(t, newStrategy(t, testCase.NewStrategy, definitions, nil), second)
+
+			for key := range third {
+				if strings.HasPrefix(key, "migration_0003") {
+					t.Errorf("expected no migration without changes, got %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFileMapMemory(t *testing.T) {
+	fileMap, err := newStrategy(t, memory.NewStrategy, syntheticDefinitions(t, 1), &entities.Options{Offline: true}).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	repository := fileMap["entity0_repository"].Result
+
+	for _, code := range []string{`table: c.Table("entity0s")`, `case "count":`, `return a.CreatedAt.Before(b.CreatedAt)`} {
+		if !strings.Contains(repository, code) {
+			t.Errorf("expected the repository to contain %s, got:\n%s", code, repository)
+		}
+	}
+
+	if _, ok := fileMap["migration_0001_create_entity0s_up"]; ok {
+		t.Errorf("the project should not have migrations")
+	}
+
+	for _, dependency := range []string{"redis", "mongo", "sqlite", "pgx"} {
+		if strings.Contains(fileMap["go_mod"].Result, dependency) {
+			t.Errorf("the project should not depend on %s", dependency)
+		}
+	}
+}
+
+func TestBuildFileMapFrameworks(t *testing.T) {
+	tests := []struct {
+		Description string
+		Framework   string
+		Templates   map[string]string
+		Files       []string
+		Dependency  string
+	}{
+		{
+			Description: "default framework",
+			Framework:   "",
+			Templates:   map[string]string{"router": "go/fiber/router.tmpl", "entity0_controller": "go/fiber/controller.tmpl"},
+			Dependency:  "github.com/gofiber/fiber/v2",
+		},
+		{
+			Description: "standard library",
+			Framework:   "nethttp",
+			Templates:   map[string]string{"router": "go/nethttp/router.tmpl", "entity0_controller": "go/nethttp/controller.tmpl"},
+			Files:       []string{"pkg/web/web.go", "pkg/web/middleware.go"},
+		},
+		{
+			Description: "chi",
+			Framework:   "chi",
+			Templates:   map[string]string{"router": "go/chi/router.tmpl", "entity0_controller": "go/nethttp/controller.tmpl"},
+			Files:       []string{"pkg/web/web.go"},
+			Dependency:  "github.com/go-chi/chi/v5",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			definitions := syntheticDefinitions(t, 1)
+			definitions.App.Stack.Framework = test.Framework
+			fileMap, err := newStrategy(t, mongodb.NewStrategy, definitions, &entities.Options{Offline: true}).BuildFileMap()
+
+			if err != nil {
+				t.Fatalf("BuildFileMap returned an error: %s", err)
+			}
+
+			for key, path := range test.Templates {
+				if fileMap[key].TemplatePath != path {
+					t.Errorf("expected %s to be rendered from %s, got %s", key, path, fileMap[key].TemplatePath)
+				}
+			}
+
+			paths := make(map[string]bool)
+
+			for _, file := range fileMap {
+				paths[file.FinalPath] = true
+			}
+
+			for _, path := range test.Files {
+				if !paths[path] {
+					t.Errorf("expected the file %s", path)
+				}
+			}
+
+			for _, dependency := range []string{"github.com/gofiber/fiber/v2 ", "github.com/go-chi/chi/v5 "} {
+				required := strings.Contains(fileMap["go_mod"].Result, dependency)
+
+				if required != (strings.TrimSpace(dependency) == test.Dependency) {
+					t.Errorf("expected go.mod to require %s only when it is the framework, got:\n%s", dependency, fileMap["go_mod"].Result)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildPostActionsFormat(t *testing.T) {
+	dir := t.TempDir()
+	stgy := newStrategy(t, mongodb.NewStrategy, syntheticDefinitions(t, 1), &entities.Options{Steps: []string{common.StepFormat}})
+	fileMap, err := stgy.BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	fileMap["main"].Result = "package main\nfunc main() {\nprintln( 1 )\n}\n"
+
+	for _, file := range fileMap {
+		path := filepath.Join(dir, filepath.FromSlash(file.FinalPath))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(file.Result), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := stgy.BuildPostActions(dir); err != nil {
+		t.Fatalf("BuildPostActions returned an error: %s", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "package main\n\nfunc main() {\n\tprintln(1)\n}\n"; string(content) != expected {
+		t.Errorf("expected the file to be formatted as:\n%s\ngot:\n%s", expected, content)
+	}
+}
+
+func TestUnknownFramework(t *testing.T) {
+	definitions := syntheticDefinitions(t, 1)
+	definitions.App.Stack.Framework = "gin"
+	_, err := mongodb.NewStrategy(definitions, nil)
+
+	if err == nil || err.Error() != `unknown Go framework "gin", the frameworks are: chi, fiber, nethttp` {
+		t.Errorf("expected the error of the unknown framework, got %v", err)
+	}
+}
+
+func TestBuildFileMapOverride(t *testing.T) {
+	shared := t.TempDir()
+	framework := t.TempDir()
+	writeTemplate(t, filepath.Join(shared, "go/controller.tmpl"), "// shared override\n")
+	writeTemplate(t, filepath.Join(framework, "go/nethttp/controller.tmpl"), "// nethttp override\n")
+
+	tests := []struct {
+		Description string
+		Framework   string
+		Dirs        []string
+		Template    string
+		Result      string
+	}{
+		{
+			Description: "shared override with the default framework",
+			Dirs:        []string{shared},
+			Template:    "go/controller.tmpl",
+			Result:      "// shared override",
+		},
+		{
+			Description: "shared override with the standard library",
+			Framework:   "nethttp",
+			Dirs:        []string{shared},
+			Template:    "go/controller.tmpl",
+			Result:      "// shared override",
+		},
+		{
+			Description: "framework override",
+			Framework:   "nethttp",
+			Dirs:        []string{framework},
+			Template:    "go/nethttp/controller.tmpl",
+			Result:      "// nethttp override",
+		},
+		{
+			Description: "no override",
+			Framework:   "nethttp",
+			Template:    "go/nethttp/controller.tmpl",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			definitions := syntheticDefinitions(t, 1)
+			definitions.App.Stack.Framework = test.Framework
+			options := &entities.Options{Offline: true, TemplateDirs: test.Dirs}
+			fileMap, err := newStrategy(t, mongodb.NewStrategy, definitions, options).BuildFileMap()
+
+			if err != nil {
+				t.Fatalf("BuildFileMap returned an error: %s", err)
+			}
+
+			controller := fileMap["entity0_controller"]
+
+			if controller.TemplatePath != test.Template {
+				t.Errorf("expected the controller to be rendered from %s, got %s", test.Template, controller.TemplatePath)
+			}
+
+			if test.Result != "" && strings.TrimSpace(controller.Result) != test.Result {
+				t.Errorf("expected the controller %q, got %q", test.Result, controller.Result)
+			}
+		})
+	}
+}
+
+func writeTemplate(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildFileMapError(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"readme.tmpl", "gitignore.tmpl", "fiber/main.tmpl"} {
+		path := filepath.Join(dir, "go", name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("{{ .Missing }}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every run reports the failing file that comes first, whatever the scheduling
+	for attempt := 0; attempt < 10; attempt++ {
+		stgy := newStrategy(t, mongodb.NewStrategy, syntheticDefinitions(t, 10), &entities.Options{TemplateDirs: []string{dir}})
+		_, err := stgy.BuildFileMap()
+
+		if err == nil || !strings.Contains(err.Error(), "rendering template gitignore:") {
+			t.Fatalf("expected the error of the gitignore template, got %v", err)
+		}
+	}
+}
+
+func BenchmarkBuildFileMap(b *testing.B) {
+	definitions := syntheticDefinitions(b, 150)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, err := newStrategy(b, mongodb.NewStrategy, definitions, nil).BuildFileMap()
+
+		if err != nil {
+			b.Fatalf("BuildFileMap returned an error: %s", err)
+		}
+	}
+}
@@ -1,33 +1,30 @@
-package mongodb
+package common
 
 import (
 	"fmt"
 	"strings"
 
-	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
 )
 
-func jsonMarshal(entity *entities.Entity) (string, error) {
-	funcMap := templates.DefaultFuncMap()
-	funcMap["jsonMarshalField"] = jsonMarshalField
-
-	return templates.Render(&templates.Template{
-		Path:    "go/json_marshal.tmpl",
-		Name:    "json_marshal",
-		Data:    entity,
-		FuncMap: funcMap,
-	})
+func (s *strategy) jsonMarshal(entity *entities.Entity) (string, error) {
+	return s.jsonCache.Render("go/json_marshal.tmpl", "json_marshal", entity)
 }
 
-func jsonMarshalField(field *entities.Field) string {
+func (s *strategy) jsonMarshalField(field *entities.Field) (string, error) {
 	switch field.Type {
 	case "int", "uint", "int32", "int64", "float32", "float64":
-		return field.Example()
+		return field.SeededExample(s.seed)
 	case "string":
-		return fmt.Sprintf("\"%s\"", field.Example())
+		example, err := field.SeededExample(s.seed)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("\"%s\"", example), nil
+	case "bool":
+		return "true", nil
 	}
-	return ""
+	return "", nil
 }
 
 func buildValidations(field *entities.Field, includeRequired bool) string {
@@ -57,13 +54,3 @@ func buildValidations(field *entities.Field, includeRequired bool) string {
 		return fmt.Sprintf("validate:\"%s\"", result)
 	}
 }
-
-func mapSort(sort string) int {
-	switch sort {
-	case "asc":
-		return 1
-	case "desc":
-		return -1
-	}
-	return 1
-}
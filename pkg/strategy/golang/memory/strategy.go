@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+)
+
+// Go types of the columns. The rows are kept as entities, so the columns are
+// only used to filter, sort and check the unique indexes.
+var Dialect = common.Dialect{
+	Types: map[string]string{
+		"id":      "string",
+		"string":  "string",
+		"bool":    "bool",
+		"int":     "int",
+		"uint":    "uint",
+		"int32":   "int32",
+		"int64":   "int64",
+		"float32": "float32",
+		"float64": "float64",
+		"time":    "time.Time",
+		"json":    "json",
+	},
+}
+
+// Database of the strategy, its templates are in go/memory
+var Database = &common.Database{
+	Name:    "memory",
+	FuncMap: Dialect.FuncMap(),
+}
+
+func NewStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	return common.NewStrategy(definitions, options, Database)
+}
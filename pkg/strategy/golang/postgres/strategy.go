@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+)
+
+// Column types of PostgreSQL, whose migrations can change the type of a column
+var Dialect = common.Dialect{
+	Types: map[string]string{
+		"id":      "TEXT",
+		"string":  "TEXT",
+		"bool":    "BOOLEAN",
+		"int":     "BIGINT",
+		"uint":    "BIGINT",
+		"int32":   "INTEGER",
+		"int64":   "BIGINT",
+		"float32": "REAL",
+		"float64": "DOUBLE PRECISION",
+		"time":    "TIMESTAMPTZ",
+		"json":    "JSONB",
+	},
+	AlterTypes: true,
+}
+
+// Database of the strategy, its templates are in go/postgres and go/sql
+var Database = &common.Database{
+	Name:    "postgres",
+	Folders: []string{"sql"},
+	FuncMap: Dialect.FuncMap(),
+	Files:   Dialect.Migrations,
+}
+
+func NewStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	return common.NewStrategy(definitions, options, Database)
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+)
+
+// Column types of SQLite. The nested entities are stored as TEXT, as a JSON
+// column type would have numeric affinity. The migrations keep the declared
+// type of a column, SQLite can not change it.
+var Dialect = common.Dialect{
+	Types: map[string]string{
+		"id":      "TEXT",
+		"string":  "TEXT",
+		"bool":    "BOOLEAN",
+		"int":     "INTEGER",
+		"uint":    "INTEGER",
+		"int32":   "INTEGER",
+		"int64":   "INTEGER",
+		"float32": "REAL",
+		"float64": "REAL",
+		"time":    "DATETIME",
+		"json":    "TEXT",
+	},
+	AlterTypes: false,
+}
+
+// Database of the strategy, its templates are in go/sqlite and go/sql
+var Database = &common.Database{
+	Name:    "sqlite",
+	Folders: []string{"sql"},
+	FuncMap: Dialect.FuncMap(),
+	Files:   Dialect.Migrations,
+}
+
+func NewStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	return common.NewStrategy(definitions, options, Database)
+}
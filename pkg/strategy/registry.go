@@ -0,0 +1,105 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Factory - Creates the strategy of a stack for the given definitions
+type Factory func(*entities.Definitions, *entities.Options) (entities.Strategy, error)
+
+var (
+	registryMu        sync.RWMutex
+	registry          = make(map[entities.Stack]Factory)
+	defaultFrameworks = make(map[string]string)
+)
+
+// Register - Makes a strategy available for a (language, framework, database) stack.
+// Packages providing their own stacks usually call it from an init function.
+// It panics if the stack is already registered or the factory is nil.
+func Register(stack entities.Stack, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("strategy: Register factory for stack %s is nil", stack))
+	}
+
+	if _, ok := registry[stack]; ok {
+		panic(fmt.Sprintf("strategy: Register called twice for stack %s", stack))
+	}
+
+	registry[stack] = factory
+}
+
+// SetDefaultFramework - Sets the framework used for a language when the
+// definitions do not specify one
+func SetDefaultFramework(language string, framework string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	defaultFrameworks[language] = framework
+}
+
+// Registered - Returns all the registered stacks, sorted by language, framework and database
+func Registered() []entities.Stack {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make([]entities.Stack, 0, len(registry))
+
+	for stack := range registry {
+		result = append(result, stack)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+
+	return result
+}
+
+// Supported - Checks that a strategy is registered for the stack, with the
+// default framework of the language when it has none
+func Supported(stack entities.Stack) error {
+	_, err := lookup(stack)
+	return err
+}
+
+// NewStrategy - Creates the strategy registered for the stack of the definitions
+func NewStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	factory, err := lookup(definitions.App.Stack)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return factory(definitions, options)
+}
+
+func lookup(stack entities.Stack) (Factory, error) {
+	registryMu.RLock()
+
+	if stack.Framework == "" {
+		stack.Framework = defaultFrameworks[stack.Language]
+	}
+
+	factory, ok := registry[stack]
+	registryMu.RUnlock()
+
+	if !ok {
+		supported := make([]string, 0)
+
+		for _, s := range Registered() {
+			supported = append(supported, s.String())
+		}
+
+		return nil, fmt.Errorf("unsupported stack %s, supported stacks are: %s", stack, strings.Join(supported, ", "))
+	}
+
+	return factory, nil
+}
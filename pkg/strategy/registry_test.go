@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+type fakeStrategy struct {
+	definitions *entities.Definitions
+}
+
+func (f *fakeStrategy) BuildFileMap() (map[string]*entities.File, error) {
+	return map[string]*entities.File{}, nil
+}
+
+func (f *fakeStrategy) BuildPostActions(string) error {
+	return nil
+}
+
+func newFakeStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	return &fakeStrategy{definitions}, nil
+}
+
+func definitionsWithStack(stack entities.Stack) *entities.Definitions {
+	return &entities.Definitions{App: &entities.App{Stack: stack}}
+}
+
+func TestRegistry(t *testing.T) {
+	stack := entities.Stack{Language: "fake", Framework: "web", Database: "memory"}
+	Register(stack, newFakeStrategy)
+	SetDefaultFramework("fake", "web")
+
+	found := false
+
+	for _, registered := range Registered() {
+		if registered == stack {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Registered should list the stack %s", stack)
+	}
+
+	for _, s := range []entities.Stack{stack, {Language: "fake", Database: "memory"}} {
+		definitions := definitionsWithStack(s)
+		result, err := NewStrategy(definitions, nil)
+
+		if err != nil {
+			t.Fatalf("NewStrategy(%s) returned an error: %s", s, err)
+		}
+
+		if result.(*fakeStrategy).definitions != definitions {
+			t.Errorf("NewStrategy(%s) should pass the definitions to the factory", s)
+		}
+	}
+
+	if err := Supported(entities.Stack{Language: "fake", Database: "memory"}); err != nil {
+		t.Errorf("Supported should accept the stack with the default framework, got %s", err)
+	}
+
+	if err := Supported(entities.Stack{Language: "go", Framework: "gin", Database: "mongodb"}); err == nil {
+		t.Errorf("Supported should reject an unknown framework")
+	}
+
+	_, err := NewStrategy(definitionsWithStack(entities.Stack{Language: "go", Database: "oracle"}), nil)
+
+	if err == nil {
+		t.Fatalf("NewStrategy with an unknown stack should return an error")
+	}
+
+	if !strings.Contains(err.Error(), "go/fiber/oracle") || !strings.Contains(err.Error(), "go/fiber/mongodb") {
+		t.Errorf("error should describe the unknown stack and the supported ones, got: %s", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a stack twice should panic")
+		}
+	}()
+
+	Register(stack, newFakeStrategy)
+}
@@ -2,18 +2,45 @@ package strategy
 
 import (
 	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
-	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/common"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/memory"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/mongodb"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/postgres"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/golang/sqlite"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript"
+	tsmongodb "github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript/mongodb"
 )
 
 const (
-	GoLang = "go"
+	GoLang  = "go"
+	Fiber   = "fiber"
+	NetHTTP = "nethttp"
+	Chi     = "chi"
+
+	TypeScript = "typescript"
+	Express    = "express"
+	Fastify    = "fastify"
+
+	MongoDB  = "mongodb"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+	Memory   = "memory"
 )
 
-func NewStrategy(definitions *entities.Definitions) entities.Strategy {
-	switch definitions.App.Stack.Language {
-	case GoLang:
-		return golang.NewStrategy(definitions)
+// Registers the stacks shipped with the engine
+func init() {
+	SetDefaultFramework(GoLang, common.DefaultFramework)
+	SetDefaultFramework(TypeScript, typescript.DefaultFramework)
+
+	// The Go strategies take the framework from the definitions
+	for _, framework := range []string{Fiber, NetHTTP, Chi} {
+		Register(entities.Stack{Language: GoLang, Framework: framework, Database: MongoDB}, mongodb.NewStrategy)
+		Register(entities.Stack{Language: GoLang, Framework: framework, Database: Postgres}, postgres.NewStrategy)
+		Register(entities.Stack{Language: GoLang, Framework: framework, Database: SQLite}, sqlite.NewStrategy)
+		Register(entities.Stack{Language: GoLang, Framework: framework, Database: Memory}, memory.NewStrategy)
 	}
 
-	return nil
+	for _, framework := range []string{Express, Fastify} {
+		Register(entities.Stack{Language: TypeScript, Framework: framework, Database: MongoDB}, tsmongodb.NewStrategy)
+	}
 }
@@ -0,0 +1,28 @@
+package mongodb
+
+import (
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript"
+)
+
+// Database of the strategy, its templates are in typescript/mongodb
+var Database = &typescript.Database{
+	Name: "mongodb",
+	FuncMap: map[string]interface{}{
+		"mapSort": mapSort,
+	},
+}
+
+func NewStrategy(definitions *entities.Definitions, options *entities.Options) (entities.Strategy, error) {
+	return typescript.NewStrategy(definitions, options, Database)
+}
+
+func mapSort(sort string) int {
+	switch sort {
+	case "asc":
+		return 1
+	case "desc":
+		return -1
+	}
+	return 1
+}
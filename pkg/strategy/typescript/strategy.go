@@ -0,0 +1,330 @@
+// Package typescript holds the strategy of the TypeScript stacks. They run on
+// Node.js with Express or Fastify, the controllers are shared by both and the
+// framework only adapts them to its requests.
+package typescript
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/danilo-medeiros/fancybuild/engine/internal/pipeline"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/render"
+	"github.com/danilo-medeiros/fancybuild/engine/internal/templates"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+// Names of the post build steps
+const (
+	StepInstall   = "install"   // Installs the dependencies with npm install, it needs network access unless they are cached
+	StepTypecheck = "typecheck" // Type checks the sources and the tests with tsc
+	StepTest      = "test"      // Runs the tests of the project with Jest, they need the database of docker-compose.yml
+)
+
+// DefaultFramework - Framework of the definitions that do not choose one
+const DefaultFramework = "express"
+
+// Frameworks - The web frameworks of the TypeScript stacks, each one has a
+// folder of templates in typescript/
+var Frameworks = []string{"express", "fastify"}
+
+// Database - What a TypeScript stack takes from its database
+type Database struct {
+	Name    string           // Folder of the templates of the database, e.g. "mongodb"
+	FuncMap template.FuncMap // Functions available to the templates, besides the default ones
+}
+
+type strategy struct {
+	*entities.Definitions
+	FileMap   map[string]*entities.File
+	Templates *templates.Overlay
+	cache     *templates.Cache
+	seed      int64 // Seed of the example values
+	options   *entities.Options
+	database  *Database
+	framework string
+	rendered  func(*entities.File)
+}
+
+// SetOnRendered - Sets the function called with every file once it is rendered
+func (s *strategy) SetOnRendered(rendered func(file *entities.File)) {
+	s.rendered = rendered
+}
+
+// Path of the template of a file, looked up in the folders of the database
+// and then of the framework, before the shared TypeScript templates
+func (s *strategy) template(name string) string {
+	return s.Templates.Lookup("typescript", name, []string{s.database.Name}, []string{s.framework})
+}
+
+func (s *strategy) BuildFileMap() (map[string]*entities.File, error) {
+	fileMap := map[string]*entities.File{
+		"main": {
+			FinalPath:    "src/main.ts",
+			TemplatePath: s.template("main"),
+		},
+		"app": {
+			FinalPath:    "src/app.ts",
+			TemplatePath: s.template("app"),
+		},
+		"router": {
+			FinalPath:    "src/router.ts",
+			TemplatePath: s.template("router"),
+		},
+		"web": {
+			FinalPath:    "src/web/web.ts",
+			TemplatePath: s.template("web"),
+		},
+		"adapter": {
+			FinalPath:    "src/web/adapter.ts",
+			TemplatePath: s.template("adapter"),
+		},
+		"validator": {
+			FinalPath:    "src/validator.ts",
+			TemplatePath: s.template("validator"),
+		},
+		"errors": {
+			FinalPath:    "src/errors.ts",
+			TemplatePath: s.template("errors"),
+		},
+		"entities": {
+			FinalPath:    "src/entities/entities.ts",
+			TemplatePath: s.template("entities"),
+		},
+		"health": {
+			FinalPath:    "src/health/controller.ts",
+			TemplatePath: s.template("health"),
+		},
+		"database": {
+			FinalPath:    "src/database.ts",
+			TemplatePath: s.template("database"),
+		},
+		"package_json": {
+			FinalPath:    "package.json",
+			TemplatePath: s.template("package_json"),
+		},
+		"tsconfig": {
+			FinalPath:    "tsconfig.json",
+			TemplatePath: s.template("tsconfig"),
+		},
+		"tsconfig_build": {
+			FinalPath:    "tsconfig.build.json",
+			TemplatePath: s.template("tsconfig_build"),
+		},
+		"jest_config": {
+			FinalPath:    "jest.config.js",
+			TemplatePath: s.template("jest_config"),
+		},
+		"gitignore": {
+			FinalPath:    ".gitignore",
+			TemplatePath: s.template("gitignore"),
+		},
+		"env": {
+			FinalPath:    ".env",
+			TemplatePath: s.template("env"),
+		},
+		"env_test": {
+			FinalPath:    ".env.test",
+			TemplatePath: s.template("env_test"),
+		},
+		"readme": {
+			FinalPath:    "README.md",
+			TemplatePath: s.template("readme"),
+		},
+		"main_test": {
+			FinalPath:    "test/main.test.ts",
+			TemplatePath: s.template("main_test"),
+		},
+		"test_utils": {
+			FinalPath:    "test/utils.ts",
+			TemplatePath: s.template("test_utils"),
+		},
+		"dockerfile": {
+			FinalPath:    "Dockerfile",
+			TemplatePath: s.template("dockerfile"),
+		},
+		"docker-compose": {
+			FinalPath:    "docker-compose.yml",
+			TemplatePath: s.template("docker-compose"),
+		},
+	}
+
+	if s.Definitions.HasAuthentication() {
+		fileMap["auth_controller"] = &entities.File{
+			FinalPath:    "src/auth/controller.ts",
+			TemplatePath: s.template("auth_controller"),
+		}
+		fileMap["auth_handler"] = &entities.File{
+			FinalPath:    "src/auth/handler.ts",
+			TemplatePath: s.template("auth_handler"),
+		}
+		fileMap["auth_service"] = &entities.File{
+			FinalPath:    "src/auth/service.ts",
+			TemplatePath: s.template("auth_service"),
+		}
+		fileMap["auth_store"] = &entities.File{
+			FinalPath:    "src/auth/store.ts",
+			TemplatePath: s.template("auth_store"),
+		}
+		fileMap["auth_test"] = &entities.File{
+			FinalPath:    "test/auth/auth.test.ts",
+			TemplatePath: s.template("auth_test"),
+		}
+	}
+
+	for _, file := range fileMap {
+		file.Data = s.Definitions
+	}
+
+	for _, entity := range s.Definitions.App.Entities {
+		var data struct {
+			*entities.Definitions
+			*entities.Entity
+		}
+
+		data.Definitions = s.Definitions
+		data.Entity = entity
+
+		if entity.HasController() {
+			fileMap[fmt.Sprintf("%s_controller", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("src/%s/controller.ts", entity.Name),
+				TemplatePath: s.template("controller"),
+				Data:         data,
+			}
+
+			// Like the Go stacks, only the create action has test coverage
+			if entity.HasAction("create") {
+				fileMap[fmt.Sprintf("%s_controller_test", entity.Name)] = &entities.File{
+					FinalPath:    fmt.Sprintf("test/%s/controller.test.ts", entity.Name),
+					TemplatePath: s.template("controller_test"),
+					Data:         data,
+				}
+			}
+		}
+
+		if entity.HasService() {
+			fileMap[fmt.Sprintf("%s_service", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("src/%s/service.ts", entity.Name),
+				TemplatePath: s.template("service"),
+				Data:         data,
+			}
+		}
+
+		if entity.HasRepository() {
+			fileMap[fmt.Sprintf("%s_repository", entity.Name)] = &entities.File{
+				FinalPath:    fmt.Sprintf("src/%s/repository.ts", entity.Name),
+				TemplatePath: s.template("repository"),
+				Data:         data,
+			}
+		}
+
+		fileMap[fmt.Sprintf("%s_entity", entity.Name)] = &entities.File{
+			FinalPath:    fmt.Sprintf("src/entities/%s.ts", entity.Name),
+			TemplatePath: s.template("entity"),
+			Data:         data,
+		}
+	}
+
+	err := render.ManifestFiles(s.Templates, s.Definitions, fileMap)
+
+	if err != nil {
+		return nil, fmt.Errorf("error adding manifest files: %v", err)
+	}
+
+	err = render.Files(s.cache, fileMap, func(finalPath string, text string) (string, error) {
+		return format(finalPath, text), nil
+	}, s.rendered)
+
+	if err != nil {
+		return nil, fmt.Errorf("error rendering file map: %v", err)
+	}
+
+	s.FileMap = fileMap
+
+	return fileMap, nil
+}
+
+func (s *strategy) BuildPostActions(projectPath string) error {
+	steps, err := s.PostSteps()
+
+	if err != nil {
+		return err
+	}
+
+	return pipeline.Run(steps, projectPath)
+}
+
+func (s *strategy) PostSteps() ([]*entities.Step, error) {
+	return pipeline.Select(s.steps(), []string{StepInstall, StepTypecheck, StepTest}, s.options)
+}
+
+// All the post build steps the strategy can run
+func (s *strategy) steps() []*entities.Step {
+	install := []string{"install", "--no-audit", "--no-fund"}
+
+	// Dependencies are only read from the npm cache
+	if s.options.Offline {
+		install = append(install, "--offline")
+	}
+
+	return []*entities.Step{
+		{Name: StepInstall, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, nil, output, "npm", install...)
+		}},
+		{Name: StepTypecheck, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, nil, output, "npx", "--no-install", "tsc", "--noEmit")
+		}},
+		{Name: StepTest, Run: func(projectPath string, output io.Writer) error {
+			return pipeline.Command(projectPath, nil, output, "npm", "test")
+		}},
+	}
+}
+
+// NewStrategy - Creates the strategy of a TypeScript stack using the given
+// database and the framework of the definitions
+func NewStrategy(definitions *entities.Definitions, options *entities.Options, database *Database) (entities.Strategy, error) {
+	if options == nil {
+		options = &entities.Options{}
+	}
+
+	framework := definitions.App.Stack.Framework
+
+	if framework == "" {
+		framework = DefaultFramework
+	}
+
+	known := false
+
+	for _, name := range Frameworks {
+		known = known || name == framework
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown TypeScript framework %q, the frameworks are: %s", framework, strings.Join(Frameworks, ", "))
+	}
+
+	s := &strategy{
+		Definitions: definitions,
+		Templates:   templates.NewOverlay(options.TemplateDirs...),
+		seed:        options.Seed,
+		options:     options,
+		database:    database,
+		framework:   framework,
+	}
+
+	funcMap := templates.DefaultFuncMap()
+	funcMap["tsType"] = tsType
+	funcMap["zod"] = zod
+	funcMap["example"] = s.example
+	funcMap["jsString"] = jsString
+	funcMap["framework"] = func() string { return framework }
+
+	for name, fn := range database.FuncMap {
+		funcMap[name] = fn
+	}
+
+	s.cache = templates.NewCache(s.Templates, funcMap)
+
+	return s, nil
+}
@@ -0,0 +1,160 @@
+package typescript_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/reader"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript"
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/strategy/typescript/mongodb"
+)
+
+func readTodoApp(t *testing.T, framework string) *entities.Definitions {
+	data, err := os.ReadFile("../../../_examples/todoapp.yaml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var definitions entities.Definitions
+
+	if _, err := reader.NewReader().Read(data, &definitions); err != nil {
+		t.Fatalf("reading definitions: %s", err)
+	}
+
+	definitions.Id = "1"
+	definitions.App.Stack = entities.Stack{Language: "typescript", Framework: framework, Database: "mongodb"}
+	return &definitions
+}
+
+// newStrategy creates a strategy that must accept the definitions
+func newStrategy(t *testing.T, factory func(*entities.Definitions, *entities.Options) (entities.Strategy, error), definitions *entities.Definitions, options *entities.Options) entities.Strategy {
+	stgy, err := factory(definitions, options)
+
+	if err != nil {
+		t.Fatalf("creating the strategy: %s", err)
+	}
+
+	return stgy
+}
+
+func TestUnknownFramework(t *testing.T) {
+	_, err := mongodb.NewStrategy(readTodoApp(t, "koa"), nil)
+
+	if err == nil || err.Error() != `unknown TypeScript framework "koa", the frameworks are: express, fastify` {
+		t.Errorf("expected the error of the unknown framework, got %v", err)
+	}
+}
+
+func TestBuildFileMap(t *testing.T) {
+	fileMap, err := newStrategy(t, mongodb.NewStrategy, readTodoApp(t, ""), nil).BuildFileMap()
+
+	if err != nil {
+		t.Fatalf("BuildFileMap returned an error: %s", err)
+	}
+
+	paths := map[string]string{
+		"main":                    "src/main.ts",
+		"auth_handler":            "src/auth/handler.ts",
+		"project_controller":      "src/project/controller.ts",
+		"project_controller_test": "test/project/controller.test.ts",
+		"project_repository":      "src/project/repository.ts",
+		"task_entity":             "src/entities/task.ts",
+		"package_json":            "package.json",
+	}
+
+	for key, path := range paths {
+		file, ok := fileMap[key]
+
+		if !ok {
+			t.Fatalf("file %s was not generated", key)
+		}
+
+		if file.FinalPath != path {
+			t.Errorf("expected %s to be written to %s, got %s", key, path, file.FinalPath)
+		}
+
+		if file.Result == "" {
+			t.Errorf("file %s was not rendered", key)
+		}
+	}
+
+	if _, ok := fileMap["task_controller"]; ok {
+		t.Errorf("the nested tasks should have no controller")
+	}
+
+	for _, code := range []string{`name: z.string().min(1, "required").min(3, "min=3").default("")`, `tasks: z.array(taskSchema).default([])`} {
+		if !strings.Contains(fileMap["project_entity"].Result, code) {
+			t.Errorf("expected the entity to contain %s, got:\n%s", code, fileMap["project_entity"].Result)
+		}
+	}
+}
+
+func TestBuildFileMapFrameworks(t *testing.T) {
+	tests := []struct {
+		Description string
+		Framework   string
+		Template    string
+		Dependency  string
+	}{
+		{
+			Description: "default framework",
+			Framework:   "",
+			Template:    "typescript/express/adapter.tmpl",
+			Dependency:  `"express":`,
+		},
+		{
+			Description: "fastify",
+			Framework:   "fastify",
+			Template:    "typescript/fastify/adapter.tmpl",
+			Dependency:  `"fastify":`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			fileMap, err := newStrategy(t, mongodb.NewStrategy, readTodoApp(t, test.Framework), nil).BuildFileMap()
+
+			if err != nil {
+				t.Fatalf("BuildFileMap returned an error: %s", err)
+			}
+
+			if fileMap["adapter"].TemplatePath != test.Template {
+				t.Errorf("expected the adapter to be rendered from %s, got %s", test.Template, fileMap["adapter"].TemplatePath)
+			}
+
+			if fileMap["project_controller"].TemplatePath != "typescript/controller.tmpl" {
+				t.Errorf("the controllers should be shared by the frameworks, got %s", fileMap["project_controller"].TemplatePath)
+			}
+
+			for _, dependency := range []string{`"express":`, `"fastify":`} {
+				required := strings.Contains(fileMap["package_json"].Result, dependency)
+
+				if required != (dependency == test.Dependency) {
+					t.Errorf("expected package.json to require %s only when it is the framework, got:\n%s", dependency, fileMap["package_json"].Result)
+				}
+			}
+		})
+	}
+}
+
+func TestPostSteps(t *testing.T) {
+	stgy := newStrategy(t, mongodb.NewStrategy, readTodoApp(t, ""), &entities.Options{SkipSteps: []string{typescript.StepTest}})
+	steps, err := stgy.(entities.SteppedStrategy).PostSteps()
+
+	if err != nil {
+		t.Fatalf("PostSteps returned an error: %s", err)
+	}
+
+	names := make([]string, 0, len(steps))
+
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+
+	if strings.Join(names, ",") != "install,typecheck" {
+		t.Errorf("expected the install and typecheck steps, got %v", names)
+	}
+}
@@ -0,0 +1,311 @@
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+func tsType(fieldType string) string {
+	switch fieldType {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	}
+	return "number"
+}
+
+// Literal of an example value of the field, used by the tests
+func (s *strategy) example(field *entities.Field) (string, error) {
+	switch field.Type {
+	case "string":
+		example, err := field.SeededExample(s.seed)
+		if err != nil {
+			return "", err
+		}
+		return jsString(example), nil
+	case "bool":
+		return "true", nil
+	}
+	return field.SeededExample(s.seed)
+}
+
+// Zod schema of a field, with a rule for each of its validations. The message
+// of a rule is the tag of the validation and its value, e.g. "min=3", so the
+// generated validator answers with the same errors as the Go stacks. The
+// schemas of the query parameters coerce the values and accept them missing,
+// otherwise a missing value is the zero value of the type, as in Go.
+func zod(field *entities.Field, query bool) (string, error) {
+	var sb strings.Builder
+
+	switch field.Type {
+	case "string":
+		sb.WriteString("z.string()")
+	case "bool":
+		if query {
+			sb.WriteString(`z.enum(["true", "false"]).transform((value) => value === "true")`)
+		} else {
+			sb.WriteString("z.boolean()")
+		}
+	default:
+		if query {
+			sb.WriteString("z.coerce.number()")
+		} else {
+			sb.WriteString("z.number()")
+		}
+
+		if strings.HasPrefix(field.Type, "int") || strings.HasPrefix(field.Type, "uint") {
+			sb.WriteString(`.int("int")`)
+		}
+
+		if strings.HasPrefix(field.Type, "uint") {
+			sb.WriteString(`.nonnegative("min=0")`)
+		}
+	}
+
+	for _, validation := range field.Validations {
+		if validation.Name == "required" && query {
+			continue
+		}
+
+		rule, err := zodRule(field.Type, validation)
+
+		if err != nil {
+			return "", fmt.Errorf("on field %s: %v", field.Name, err)
+		}
+
+		sb.WriteString(rule)
+	}
+
+	if query {
+		sb.WriteString(".optional()")
+		return sb.String(), nil
+	}
+
+	switch field.Type {
+	case "string":
+		sb.WriteString(`.default("")`)
+	case "bool":
+		sb.WriteString(".default(false)")
+	default:
+		sb.WriteString(".default(0)")
+	}
+
+	return sb.String(), nil
+}
+
+func zodRule(fieldType string, validation *entities.Validation) (string, error) {
+	message := jsString(validation.Name)
+
+	if validation.Value != "" && validation.Name != "required" {
+		message = jsString(validation.Name + "=" + validation.Value)
+	}
+
+	if validation.Name == "required" {
+		switch fieldType {
+		case "string":
+			return fmt.Sprintf(".min(1, %s)", message), nil
+		case "bool":
+			return fmt.Sprintf(".refine((value) => value, %s)", message), nil
+		}
+		return fmt.Sprintf(".refine((value) => value !== 0, %s)", message), nil
+	}
+
+	if validation.Name == "email" {
+		if fieldType != "string" {
+			return "", fmt.Errorf("email validation of a %s", fieldType)
+		}
+		return fmt.Sprintf(".email(%s)", message), nil
+	}
+
+	if validation.Name == "oneof" {
+		values := make([]string, 0)
+
+		for _, value := range strings.Fields(validation.Value) {
+			literal, err := literal(fieldType, value)
+
+			if err != nil {
+				return "", err
+			}
+
+			values = append(values, literal)
+		}
+
+		return fmt.Sprintf(".refine((value) => [%s].includes(value), %s)", strings.Join(values, ", "), message), nil
+	}
+
+	if validation.Name == "eq" || validation.Name == "ne" {
+		value, err := literal(fieldType, validation.Value)
+
+		if err != nil {
+			return "", err
+		}
+
+		operator := "==="
+
+		if validation.Name == "ne" {
+			operator = "!=="
+		}
+
+		return fmt.Sprintf(".refine((value) => value %s %s, %s)", operator, value, message), nil
+	}
+
+	// The other validations limit the length of the strings and the value of the numbers
+	if fieldType == "string" {
+		n, err := strconv.Atoi(validation.Value)
+
+		if err != nil {
+			return "", fmt.Errorf("on parsing %q validation: %v", validation.Name, err)
+		}
+
+		switch validation.Name {
+		case "min", "gte":
+			return fmt.Sprintf(".min(%d, %s)", n, message), nil
+		case "gt":
+			return fmt.Sprintf(".min(%d, %s)", n+1, message), nil
+		case "max", "lte":
+			return fmt.Sprintf(".max(%d, %s)", n, message), nil
+		case "lt":
+			return fmt.Sprintf(".max(%d, %s)", n-1, message), nil
+		case "len":
+			return fmt.Sprintf(".length(%d, %s)", n, message), nil
+		}
+	}
+
+	if fieldType == "bool" {
+		return "", fmt.Errorf("%s validation of a bool", validation.Name)
+	}
+
+	value, err := literal(fieldType, validation.Value)
+
+	if err != nil {
+		return "", err
+	}
+
+	switch validation.Name {
+	case "min", "gte":
+		return fmt.Sprintf(".gte(%s, %s)", value, message), nil
+	case "gt":
+		return fmt.Sprintf(".gt(%s, %s)", value, message), nil
+	case "max", "lte":
+		return fmt.Sprintf(".lte(%s, %s)", value, message), nil
+	case "lt":
+		return fmt.Sprintf(".lt(%s, %s)", value, message), nil
+	case "len":
+		return fmt.Sprintf(".refine((value) => value === %s, %s)", value, message), nil
+	}
+
+	return "", fmt.Errorf("unknown validation %q", validation.Name)
+}
+
+// Literal of a validation value for a field of the given type
+func literal(fieldType string, value string) (string, error) {
+	switch fieldType {
+	case "string":
+		return jsString(value), nil
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "", fmt.Errorf("on parsing %q: %v", value, err)
+		}
+		return strings.ToLower(value), nil
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "", fmt.Errorf("on parsing %q: %v", value, err)
+	}
+
+	return value, nil
+}
+
+// The JSON encoding of a string is a valid JavaScript string
+func jsString(value string) string {
+	result, _ := json.Marshal(value)
+	return string(result)
+}
+
+// Patterns used by format, compiled once as it runs for every rendered file
+var (
+	openingLinePattern = regexp.MustCompile(`[{(\[]$`)
+	closingLinePattern = regexp.MustCompile(`^[})\]][})\];,]*$`)
+	commentLinePattern = regexp.MustCompile(`^(//|/\*|\*)`)
+)
+
+// Removes the blank lines left by the actions of the templates. The blank
+// lines of the TypeScript and JavaScript files are added back between the
+// top level statements and around the blocks, like templates.SimpleFormat
+// does for Go. The JSON files get no blank lines, and the runs of blank lines
+// of the other files become one.
+func format(finalPath string, text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	result := make([]string, 0, len(lines))
+
+	switch path.Ext(finalPath) {
+	case ".ts", ".js":
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			if len(result) > 0 && separated(result[len(result)-1], line) {
+				result = append(result, "")
+			}
+
+			result = append(result, strings.TrimRight(line, " \t"))
+		}
+	case ".json":
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				result = append(result, line)
+			}
+		}
+	default:
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" && (len(result) == 0 || result[len(result)-1] == "") {
+				continue
+			}
+			result = append(result, strings.TrimRight(line, " \t"))
+		}
+	}
+
+	return strings.Join(result, "\n") + "\n"
+}
+
+// Whether a blank line goes between two consecutive lines of code
+func separated(previous string, line string) bool {
+	trimmedPrevious := strings.TrimSpace(previous)
+	trimmed := strings.TrimSpace(line)
+	topLevel := trimmedPrevious == previous && trimmed == line
+
+	switch {
+	// Lines such as "}," or "} catch (err) {" close or continue the block before them
+	case strings.ContainsAny(trimmed[:1], "})]"):
+		return false
+	case closingLinePattern.MatchString(trimmedPrevious):
+		return true
+	// Comments stick to what they describe, but the protected regions are apart
+	case commentLinePattern.MatchString(trimmedPrevious):
+		return topLevel && strings.HasPrefix(trimmedPrevious, "// fancybuild:end")
+	case openingLinePattern.MatchString(trimmedPrevious):
+		return false
+	case commentLinePattern.MatchString(trimmed), openingLinePattern.MatchString(trimmed):
+		return true
+	case topLevel:
+		imports := strings.HasPrefix(trimmedPrevious, "import ") && strings.HasPrefix(trimmed, "import ")
+		return !imports && !(declaration(trimmedPrevious) && declaration(trimmed))
+	}
+
+	// The declarations of a block are apart from its other statements
+	sameIndentation := len(previous)-len(trimmedPrevious) == len(line)-len(trimmed)
+	return sameIndentation && declaration(trimmedPrevious) != declaration(trimmed) && !strings.HasPrefix(trimmed, "return")
+}
+
+func declaration(line string) bool {
+	line = strings.TrimPrefix(line, "export ")
+	return strings.HasPrefix(line, "const ") || strings.HasPrefix(line, "let ")
+}
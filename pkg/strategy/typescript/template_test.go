@@ -0,0 +1,105 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/danilo-medeiros/fancybuild/engine/pkg/entities"
+)
+
+func TestZod(t *testing.T) {
+	tests := []struct {
+		Description string
+		Field       *entities.Field
+		Query       bool
+		Expected    string
+		Error       bool
+	}{
+		{
+			Description: "string with its length",
+			Field:       &entities.Field{Name: "title", Type: "string", Validations: []*entities.Validation{{Name: "required", Value: "true"}, {Name: "min", Value: "3"}, {Name: "lt", Value: "40"}}},
+			Expected:    `z.string().min(1, "required").min(3, "min=3").max(39, "lt=40").default("")`,
+		},
+		{
+			Description: "string query",
+			Field:       &entities.Field{Name: "email", Type: "string", Validations: []*entities.Validation{{Name: "required", Value: "true"}, {Name: "email"}}},
+			Query:       true,
+			Expected:    `z.string().email("email").optional()`,
+		},
+		{
+			Description: "string options",
+			Field:       &entities.Field{Name: "status", Type: "string", Validations: []*entities.Validation{{Name: "oneof", Value: "open closed"}}},
+			Expected:    `z.string().refine((value) => ["open", "closed"].includes(value), "oneof=open closed").default("")`,
+		},
+		{
+			Description: "unsigned integer",
+			Field:       &entities.Field{Name: "count", Type: "uint", Validations: []*entities.Validation{{Name: "max", Value: "100"}}},
+			Expected:    `z.number().int("int").nonnegative("min=0").lte(100, "max=100").default(0)`,
+		},
+		{
+			Description: "float query",
+			Field:       &entities.Field{Name: "price", Type: "float64", Validations: []*entities.Validation{{Name: "gt", Value: "0"}}},
+			Query:       true,
+			Expected:    `z.coerce.number().gt(0, "gt=0").optional()`,
+		},
+		{
+			Description: "bool",
+			Field:       &entities.Field{Name: "done", Type: "bool"},
+			Expected:    `z.boolean().default(false)`,
+		},
+		{
+			Description: "invalid value",
+			Field:       &entities.Field{Name: "count", Type: "int", Validations: []*entities.Validation{{Name: "min", Value: "one"}}},
+			Error:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			result, err := zod(test.Field, test.Query)
+
+			if (err != nil) != test.Error {
+				t.Fatalf("expected error %v, got %v", test.Error, err)
+			}
+
+			if result != test.Expected {
+				t.Errorf("expected %s, got %s", test.Expected, result)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		Description string
+		Path        string
+		Text        string
+		Expected    string
+	}{
+		{
+			Description: "typescript",
+			Path:        "src/app.ts",
+			Text:        "import a from \"a\";\n\nimport b from \"b\";\n// run - Runs\n\nexport function run() {\n  const x = a();\n\n  b(x);\n  try {\n    x();\n  } catch (err) {\n    b(err);\n  }\n  return x;\n}\nrun();",
+			Expected:    "import a from \"a\";\nimport b from \"b\";\n\n// run - Runs\nexport function run() {\n  const x = a();\n\n  b(x);\n\n  try {\n    x();\n  } catch (err) {\n    b(err);\n  }\n\n  return x;\n}\n\nrun();\n",
+		},
+		{
+			Description: "json",
+			Path:        "package.json",
+			Text:        "{\n\n  \"name\": \"app\"\n\n}",
+			Expected:    "{\n  \"name\": \"app\"\n}\n",
+		},
+		{
+			Description: "other files",
+			Path:        "README.md",
+			Text:        "# App\n\n\n\nRun it  \n",
+			Expected:    "# App\n\nRun it\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			if result := format(test.Path, test.Text); result != test.Expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.Expected, result)
+			}
+		})
+	}
+}